@@ -13,9 +13,11 @@ governing permissions and limitations under the License.
 package cmd
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"strings"
 	"swinch/domain/application"
 )
 
@@ -29,9 +31,9 @@ var applicationCmd = &cobra.Command{
 		ValidateConfigFile()
 		ValidateConfig()
 	},
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		subCmd := cmd.Parent().Use
-		cmdAppAction(subCmd)
+		return cmdAppAction(cmd.Context(), subCmd)
 	},
 }
 
@@ -42,12 +44,13 @@ var DeleteAppCmd = *applicationCmd
 func init() {
 	// import flags
 	ImportAppCmd.Flags().StringVarP(&applicationName, "application", "a", "", "Application name")
-	ImportAppCmd.Flags().StringVarP(&filePath, "file", "f", "", "JSON file input")
+	ImportAppCmd.Flags().StringVarP(&filePath, "file", "f", "", "Import from Spinnaker JSON file or directory, non recursive")
 	ImportAppCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Generated chart output path")
-	ImportAppCmd.Flags().StringVarP(&chartName, "chart", "n", "", "Specify chart name for imported pipeline")
+	ImportAppCmd.Flags().StringVarP(&chartName, "chart", "n", "", "Specify chart name for imported application")
 	ImportAppCmd.Flags().BoolVarP(&protectedImport, "protected-import", "", false, "Protect already created chart from overwriting")
-	ImportAppCmd.MarkFlagRequired("application")
+	ImportAppCmd.Flags().StringVarP(&valueRulesPath, "value-rules", "", "", "Yaml file with the rules extracting chart values, overrides the default rules")
 	ImportAppCmd.MarkFlagRequired("output")
+	ImportAppCmd.PreRun = importPreRun
 	importCmd.AddCommand(&ImportAppCmd)
 
 	// delete flags
@@ -59,14 +62,54 @@ func init() {
 	planCmd.AddCommand(&PlanAppCmd)
 }
 
-func cmdAppAction(subCmd string) {
+func cmdAppAction(ctx context.Context, subCmd string) error {
 	a := application.Application{}
 	switch subCmd {
 	case deleteAction:
-		a.Delete(applicationName)
+		a.Metadata.Name = applicationName
+		return a.Destroy(ctx)
 	case importAction:
-		fmt.Println("Import TBA")
+		return importApplication(ctx, a)
 	default:
-		log.Fatalf("Unknown application command")
+		return errors.New("unknown application command")
 	}
 }
+
+func importApplication(ctx context.Context, a application.Application) error {
+	files, ok, err := importFiles()
+	if err != nil {
+		return err
+	}
+	if ok {
+		for _, appJSON := range files {
+			manifest, err := a.ImportJSON(appJSON)
+			if err != nil {
+				return err
+			}
+			err = writeApplicationChart(manifest)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if applicationName == "" {
+		return errors.New("required flag(s) \"application\" or \"file\" not set")
+	}
+	manifest, err := a.Import(ctx, applicationName)
+	if err != nil {
+		return err
+	}
+	return writeApplicationChart(manifest)
+}
+
+func writeApplicationChart(manifest application.Manifest) error {
+	kind := strings.ToLower(application.Kind)
+	chartPath, err := writeImportChart(manifest.Metadata.Name, kind, map[string]interface{}{kind: manifest})
+	if err != nil {
+		return err
+	}
+	log.Infof("Application '%v' imported in chart '%v'", manifest.Metadata.Name, chartPath)
+	return nil
+}
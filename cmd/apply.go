@@ -13,52 +13,360 @@ governing permissions and limitations under the License.
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/manifoldco/promptui"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"os"
+	"sort"
+	"strings"
+	"swinch/domain/application"
+	"swinch/domain/change"
 	"swinch/domain/manifest"
+	"swinch/domain/pipeline"
+	"swinch/domain/stages"
+	"swinch/spincli"
+	"sync"
 )
 
+// errApplyCancelled is returned when the plan is not confirmed, nothing is saved
+var errApplyCancelled = errors.New("apply cancelled, nothing saved")
+
 // applyCmd represents the apply command
 var applyCmd = &cobra.Command{
 	Use:   "apply",
 	Short: "Apply or sync an Application or Pipeline from a manifest",
-	Long:  "Apply or sync an Application or Pipeline from a manifest",
+	Long: `Apply or sync an Application or Pipeline from a manifest.
+The plan of all the manifests is computed and confirmed before saving anything.`,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		SetLogLevel(logLevel)
 		ValidateConfigFile()
 		ValidateConfig()
 	},
-	Run: func(cmd *cobra.Command, args []string) {
-		runApply()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApply(cmd.Context())
 	},
 }
 
 func init() {
-	applyCmd.Flags().StringVarP(&filePath, "file", "f", "", "Manifest file or directory, non recursive")
-	applyCmd.Flags().BoolVarP(&plan, "plan", "p", true, "Display plan before apply, no user input.")
+	applyCmd.Flags().StringSliceVarP(&manifestPaths, "file", "f", nil, "Manifest files, directories or glob patterns, repeatable, - reads the manifests from stdin")
+	applyCmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "Read the manifest directories recursively")
+	applyCmd.Flags().BoolVarP(&plan, "plan", "p", true, "Display the plan changes before the confirmation")
+	applyCmd.Flags().BoolVarP(&prunePipelines, "prune", "", false, "Delete the pipelines of the applied applications missing from the manifests")
+	applyCmd.Flags().StringSliceVarP(&pruneAllowlist, "prune-allowlist", "", nil, "Pipelines not managed by swinch and never pruned, accepts name patterns like 'manual-*'")
+	applyCmd.Flags().BoolVarP(&autoApprove, "auto-approve", "", false, "Apply the plan without confirmation")
+	applyCmd.Flags().IntVarP(&parallelism, "parallelism", "", 1, "Number of manifests planned and pipelines of an application saved concurrently")
 	applyCmd.MarkFlagRequired("file")
 	rootCmd.AddCommand(applyCmd)
 }
 
-func Apply(m manifest.M, dryRun, plan bool) {
-	m.Apply(dryRun, plan)
+// the backends storing the applied applications and pipelines, Spinnaker Gate is used if they are not set
+var (
+	applicationBackend spincli.ApplicationBackend
+	pipelineBackend    spincli.PipelineBackend
+)
+
+// runPrompt runs the confirmation prompt, the prompt reads the user answer from the terminal
+var runPrompt = func(prompt promptui.Prompt) (string, error) {
+	return prompt.Run()
+}
+
+// plannedChange is a change of the apply plan with the action saving it
+// the resource snapshot is taken before saving, to restore the resource if the apply fails
+type plannedChange struct {
+	change   change.Change
+	save     func(context.Context) error
+	resource snapshotter
 }
 
-func runApply() {
+type snapshotter interface {
+	Snapshot(context.Context) ([]byte, error)
+	Restore(context.Context, []byte) error
+}
+
+func runApply(ctx context.Context) error {
 	m := manifest.NewManifest{}
-	manifests := m.GetManifests(filePath)
+	manifests, err := m.GetManifests(manifestPaths, recursive)
+	if err != nil {
+		return err
+	}
+	return applyManifests(ctx, manifests)
+}
+
+// applyManifests plans all the manifests, applications before pipelines, and saves the changes once the plan is confirmed
+// nothing is saved if any manifest fails to plan, with --prune the plan deletes the unmanaged pipelines
+func applyManifests(ctx context.Context, manifests []manifest.Manifest) error {
+	options, err := stageOptions()
+	if err != nil {
+		return err
+	}
+	planned := make([]plannedChange, 0)
+	managed := make(map[string]map[string]bool)
+	charts := make(map[string]bool)
+
+	r := manifestRun{action: "plan"}
+	for _, kind := range []string{application.Kind, pipeline.Kind} {
+		kindManifests := make([]manifest.Manifest, 0)
+		for _, newManifest := range manifests {
+			if newManifest.Kind == kind {
+				kindManifests = append(kindManifests, newManifest)
+			}
+		}
+
+		// the manifests are compared with Spinnaker concurrently, then reported in order
+		results := make([]plannedChange, len(kindManifests))
+		errs := make([]error, len(kindManifests))
+		runParallel(parallelism, len(kindManifests), func(i int) error {
+			results[i], errs[i] = planManifest(ctx, kindManifests[i], options)
+			return nil
+		})
+
+		for i, newManifest := range kindManifests {
+			pc := results[i]
+			r.run(newManifest.Name(), func() error {
+				if errs[i] != nil {
+					return errs[i]
+				}
+				planned = append(planned, pc)
+				if p, ok := pc.resource.(*pipeline.Pipeline); ok {
+					managePipeline(managed, p.Metadata.Application, p.Metadata.Name)
+					if p.Metadata.Owner != nil {
+						charts[p.Metadata.Owner.Chart] = true
+					}
+				}
+				return nil
+			})
+		}
+	}
+	if err := r.summary(); err != nil {
+		log.Errorf("Nothing saved, fix the failed manifests and apply again")
+		return err
+	}
+
+	if prunePipelines {
+		deletions, err := planPrune(ctx, managed, charts)
+		if err != nil {
+			return err
+		}
+		planned = append(planned, deletions...)
+	}
+
+	p := change.Plan{}
+	for _, pc := range planned {
+		p.Add(pc.change)
+	}
+	if plan {
+		if err := p.Write(os.Stdout, change.TableFormat); err != nil {
+			return err
+		}
+	}
+	log.Infof("Plan: %v", p.Summary())
+	if !p.HasChanges() {
+		log.Infof("No changes to apply")
+		return nil
+	}
+
+	ok, err := confirm("Apply the plan")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errApplyCancelled
+	}
+
+	changes := make([]plannedChange, 0)
+	for _, pc := range planned {
+		if pc.change.Action != change.NoOp {
+			changes = append(changes, pc)
+		}
+	}
+	return applyPlan(ctx, changes)
+}
+
+// applyPlan snapshots the changed resources then saves the changes, applications one by one then the pipelines of each application concurrently
+// a failing change stops the apply and the saved changes are restored from the snapshots
+func applyPlan(ctx context.Context, changes []plannedChange) error {
+	snapshots := make([][]byte, len(changes))
+	err := runParallel(parallelism, len(changes), func(i int) error {
+		snapshot, err := changes[i].resource.Snapshot(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to snapshot %v, nothing saved: %w", changes[i].change.Resource(), err)
+		}
+		snapshots[i] = snapshot
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	saved := make([]bool, len(changes))
+	var failed []string
+	var mutex sync.Mutex
+	for _, batch := range applyBatches(changes) {
+		runParallel(parallelism, len(batch), func(i int) error {
+			pc := changes[batch[i]]
+			err := pc.save(ctx)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				log.Errorf("Failed to apply %v: %v", pc.change.Resource(), err)
+				failed = append(failed, pc.change.Resource())
+				return err
+			}
+			saved[batch[i]] = true
+			return nil
+		})
+		if len(failed) > 0 {
+			break
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	savedChanges := make([]plannedChange, 0)
+	savedSnapshots := make([][]byte, 0)
+	for i := range changes {
+		if saved[i] {
+			savedChanges = append(savedChanges, changes[i])
+			savedSnapshots = append(savedSnapshots, snapshots[i])
+		}
+	}
+	if len(savedChanges) == 0 {
+		return fmt.Errorf("apply failed for %v, nothing saved", strings.Join(failed, ", "))
+	}
+	if rollbackErr := rollback(ctx, savedChanges, savedSnapshots); rollbackErr != nil {
+		return fmt.Errorf("apply failed for %v and %w", strings.Join(failed, ", "), rollbackErr)
+	}
+	return fmt.Errorf("apply failed for %v, the %d saved changes were rolled back", strings.Join(failed, ", "), len(savedChanges))
+}
+
+// applyBatches groups the change indexes saved together, each application change alone and the pipeline changes by application
+func applyBatches(changes []plannedChange) [][]int {
+	batches := make([][]int, 0)
+	pipelineBatches := make(map[string]int)
+	for i, pc := range changes {
+		if pc.change.Kind != pipeline.Kind {
+			batches = append(batches, []int{i})
+			continue
+		}
+		batch, ok := pipelineBatches[pc.change.Application]
+		if !ok {
+			batch = len(batches)
+			pipelineBatches[pc.change.Application] = batch
+			batches = append(batches, nil)
+		}
+		batches[batch] = append(batches[batch], i)
+	}
+	return batches
+}
+
+// rollback restores the snapshots of the saved changes in reverse order and reports the restored resources
+func rollback(ctx context.Context, changes []plannedChange, snapshots [][]byte) error {
+	restored := make([]string, 0)
+	failed := make([]string, 0)
+	for i := len(changes) - 1; i >= 0; i-- {
+		name := changes[i].change.Resource()
+		err := changes[i].resource.Restore(ctx, snapshots[i])
+		if err != nil {
+			log.Errorf("Failed to roll back %v: %v", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		restored = append(restored, name)
+	}
+
+	log.Warnf("Rolled back %d of %d saved changes:", len(restored), len(changes))
+	for _, name := range restored {
+		log.Warnf("  %v", name)
+	}
+	if len(failed) > 0 {
+		log.Errorf("Failed to roll back %d changes, fix them manually:", len(failed))
+		for _, name := range failed {
+			log.Errorf("  %v", name)
+		}
+		return fmt.Errorf("rollback failed for %d of %d saved changes", len(failed), len(changes))
+	}
+	return nil
+}
+
+// planManifest loads a manifest and compares it with Spinnaker
+func planManifest(ctx context.Context, newManifest manifest.Manifest, options stages.ProcessOptions) (plannedChange, error) {
+	resource, err := loadManifest(newManifest, options)
+	if err != nil {
+		return plannedChange{}, err
+	}
+	c, err := resource.Change(ctx)
+	if err != nil {
+		return plannedChange{}, err
+	}
+	return plannedChange{change: c, save: resource.Save, resource: resource}, nil
+}
 
-	// Application creation should run before pipelines creation
-	for _, newManifest := range manifests {
-		switch newManifest.Kind {
-		case m.Application.GetKind():
-			Apply(m.Application.Load(newManifest), false, plan)
+// loadManifest loads a manifest in a new Application or Pipeline, the pipeline stages are processed with the options
+func loadManifest(newManifest manifest.Manifest, options stages.ProcessOptions) (manifest.M, error) {
+	switch newManifest.Kind {
+	case application.Kind:
+		a := &application.Application{Backend: applicationBackend}
+		return a.Load(newManifest)
+	case pipeline.Kind:
+		p := &pipeline.Pipeline{Backend: pipelineBackend, StageOptions: options}
+		return p.Load(newManifest)
+	default:
+		return nil, fmt.Errorf("unknown manifest Kind: %v", newManifest.Kind)
+	}
+}
+
+// managePipeline records an applied pipeline, only the applications with applied pipelines are pruned
+func managePipeline(managed map[string]map[string]bool, appName, pipeName string) {
+	if managed[appName] == nil {
+		managed[appName] = make(map[string]bool)
+	}
+	managed[appName][pipeName] = true
+}
+
+func sortedNames(managed map[string]map[string]bool) []string {
+	names := make([]string, 0)
+	for name := range managed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// confirm asks the user to confirm an action, the action is confirmed without asking with --auto-approve
+func confirm(label string) (bool, error) {
+	if autoApprove {
+		return true, nil
+	}
+	prompt := promptui.Prompt{Label: label, IsConfirm: true}
+	if manifestsFromStdin() {
+		// stdin is taken by the manifests, the confirmation is read from the terminal
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			return false, fmt.Errorf("the manifests are read from stdin and no terminal is available to confirm, use --auto-approve")
 		}
+		defer tty.Close()
+		prompt.Stdin = tty
+	}
+	_, err := runPrompt(prompt)
+	if errors.Is(err, promptui.ErrAbort) {
+		return false, nil
 	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
-	for _, newManifest := range manifests {
-		switch newManifest.Kind {
-		case m.Pipeline.GetKind():
-			Apply(m.Pipeline.Load(newManifest), false, plan)
+// manifestsFromStdin reports if the manifests are read from stdin with -f -
+func manifestsFromStdin() bool {
+	for _, path := range manifestPaths {
+		if path == "-" {
+			return true
 		}
 	}
+	return false
 }
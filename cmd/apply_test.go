@@ -0,0 +1,380 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-test/deep"
+	"github.com/manifoldco/promptui"
+	"strings"
+	"swinch/domain/application"
+	"swinch/domain/change"
+	"swinch/domain/manifest"
+	"swinch/domain/pipeline"
+	"swinch/spincli"
+	_ "swinch/testing"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testApplication = `
+apiVersion: spinnaker.adobe.com/alpha1
+kind: Application
+metadata:
+  name: test
+spec:
+  email: test@example.com
+`
+
+var testPipeline = pipelineDocument("test", "deploy")
+
+func TestApplyFailingPlan(t *testing.T) {
+	memory := useMemory(t)
+	autoApprove = true
+	brokenPipeline := `
+apiVersion: spinnaker.adobe.com/alpha1
+kind: Pipeline
+metadata:
+  name: broken
+  application: test
+spec:
+  stages:
+    - name: wait
+      type: waitForever
+`
+	err := applyManifests(context.Background(), decode(t, testApplication, testPipeline, brokenPipeline))
+	if err == nil {
+		t.Fatal("expected the apply to fail")
+	}
+	if app := getApplication(t, memory, "test"); len(app) != 0 {
+		t.Errorf("expected nothing saved, got application %s", app)
+	}
+}
+
+func TestApplyDeclined(t *testing.T) {
+	memory := useMemory(t)
+	runPrompt = func(promptui.Prompt) (string, error) {
+		return "", promptui.ErrAbort
+	}
+
+	err := applyManifests(context.Background(), decode(t, testApplication, testPipeline))
+	if !errors.Is(err, errApplyCancelled) {
+		t.Errorf("expected the apply to be cancelled, got %v", err)
+	}
+	if app := getApplication(t, memory, "test"); len(app) != 0 {
+		t.Errorf("expected nothing saved, got application %s", app)
+	}
+}
+
+func TestApplyApproved(t *testing.T) {
+	memory := useMemory(t)
+	runPrompt = func(promptui.Prompt) (string, error) {
+		return "y", nil
+	}
+
+	if err := applyManifests(context.Background(), decode(t, testApplication, testPipeline)); err != nil {
+		t.Fatal(err)
+	}
+	if app := getApplication(t, memory, "test"); len(app) == 0 {
+		t.Error("expected the application saved")
+	}
+	if pipe := getPipeline(t, memory, "test", "deploy"); len(pipe) == 0 {
+		t.Error("expected the pipeline saved")
+	}
+}
+
+func TestApplyRollback(t *testing.T) {
+	memory, backends := useFailingMemory(t)
+	existingApp, existingPipe := saveExisting(t, memory)
+	autoApprove = true
+	backends.failSave = 5
+
+	err := applyManifests(context.Background(), rollbackManifests(t))
+	if err == nil || !strings.Contains(err.Error(), "apply failed for Pipeline/broken, the 4 saved changes were rolled back") {
+		t.Fatalf("expected the apply to be rolled back, got %v", err)
+	}
+
+	// the saved changes are restored in reverse order, the created application and pipeline are deleted
+	expected := []string{
+		"save Application/test",
+		"save Application/other",
+		"save Pipeline/deploy",
+		"save Pipeline/notify",
+		"save Pipeline/broken",
+		"delete Pipeline/notify",
+		"save Pipeline/deploy",
+		"delete Application/other",
+		"save Application/test",
+	}
+	if diff := deep.Equal(backends.calls, expected); diff != nil {
+		t.Error(diff)
+	}
+	checkRolledBack(t, memory, existingApp, existingPipe)
+}
+
+func TestApplyRollbackFailure(t *testing.T) {
+	memory, backends := useFailingMemory(t)
+	existingApp, _ := saveExisting(t, memory)
+	autoApprove = true
+	backends.failSave = 5
+	backends.failDelete = "Application/other"
+
+	// the restore failure is reported along the apply failure, the other changes are still restored
+	err := applyManifests(context.Background(), rollbackManifests(t))
+	if err == nil || !strings.Contains(err.Error(), "apply failed for Pipeline/broken and rollback failed for 1 of 4 saved changes") {
+		t.Fatalf("expected the apply and rollback failures, got %v", err)
+	}
+	if diff := deep.Equal(getApplication(t, memory, "test"), existingApp); diff != nil {
+		t.Error(diff)
+	}
+	if app := getApplication(t, memory, "other"); len(app) == 0 {
+		t.Error("expected the application failing to roll back to be left")
+	}
+}
+
+func TestApplyParallelism(t *testing.T) {
+	memory, backends := useFailingMemory(t)
+	saveExisting(t, memory)
+	autoApprove = true
+	parallelism = 2
+	backends.saveDelay = 20 * time.Millisecond
+
+	manifests := make([]string, 0)
+	for i := 1; i <= 6; i++ {
+		manifests = append(manifests, pipelineDocument("test", fmt.Sprintf("pipeline-%d", i)))
+	}
+	if err := applyManifests(context.Background(), decode(t, manifests...)); err != nil {
+		t.Fatal(err)
+	}
+	if backends.maxInFlight != 2 {
+		t.Errorf("expected 2 pipelines saved at once, got %d", backends.maxInFlight)
+	}
+}
+
+func TestApplyParallelFailure(t *testing.T) {
+	memory, backends := useFailingMemory(t)
+	saveExisting(t, memory)
+	autoApprove = true
+	parallelism = 2
+	backends.saveDelay = 20 * time.Millisecond
+	backends.failSave = 2
+
+	manifests := make([]string, 0)
+	for i := 1; i <= 6; i++ {
+		manifests = append(manifests, pipelineDocument("test", fmt.Sprintf("pipeline-%d", i)))
+	}
+	err := applyManifests(context.Background(), decode(t, manifests...))
+	if err == nil || !strings.Contains(err.Error(), "the 1 saved changes were rolled back") {
+		t.Fatalf("expected the save in flight rolled back, got %v", err)
+	}
+
+	// no save starts once a save failed, the save in flight when the failure happened is deleted
+	operations := make(map[string]int)
+	for _, call := range backends.calls {
+		operations[strings.Fields(call)[0]]++
+	}
+	if diff := deep.Equal(operations, map[string]int{"save": 2, "delete": 1}); diff != nil {
+		t.Errorf("%v, calls: %v", diff, backends.calls)
+	}
+	for i := 1; i <= 6; i++ {
+		if pipe := getPipeline(t, memory, "test", fmt.Sprintf("pipeline-%d", i)); len(pipe) != 0 {
+			t.Errorf("expected pipeline-%d rolled back, got %s", i, pipe)
+		}
+	}
+}
+
+func TestApplyBatches(t *testing.T) {
+	changes := []plannedChange{
+		{change: change.Change{Kind: application.Kind, Application: "first", Name: "first"}},
+		{change: change.Change{Kind: pipeline.Kind, Application: "first", Name: "deploy"}},
+		{change: change.Change{Kind: application.Kind, Application: "second", Name: "second"}},
+		{change: change.Change{Kind: pipeline.Kind, Application: "second", Name: "deploy"}},
+		{change: change.Change{Kind: pipeline.Kind, Application: "first", Name: "notify"}},
+	}
+	if diff := deep.Equal(applyBatches(changes), [][]int{{0}, {1, 4}, {2}, {3}}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+// useMemory applies the manifests to an in-memory Spinnaker, the apply flags are reset once the test is done
+func useMemory(t *testing.T) *spincli.Memory {
+	memory := spincli.NewMemory()
+	applicationBackend, pipelineBackend = memory.Applications(), memory.Pipelines()
+	prompt, approve, prune, workers := runPrompt, autoApprove, prunePipelines, parallelism
+	t.Cleanup(func() {
+		applicationBackend, pipelineBackend = nil, nil
+		runPrompt, autoApprove, prunePipelines, parallelism = prompt, approve, prune, workers
+	})
+	return memory
+}
+
+// useFailingMemory applies the manifests to an in-memory Spinnaker recording the saves and deletes
+func useFailingMemory(t *testing.T) (*spincli.Memory, *failingBackends) {
+	memory := useMemory(t)
+	backends := &failingBackends{}
+	applicationBackend = failingApplications{memory.Applications(), backends}
+	pipelineBackend = failingPipelines{memory.Pipelines(), backends}
+	return memory, backends
+}
+
+// failingBackends records the saves and deletes of the test backends, the failSave-th save and the deletes of failDelete fail
+// the successful saves take saveDelay, the most saves running at once is kept in maxInFlight
+type failingBackends struct {
+	mutex       sync.Mutex
+	calls       []string
+	saves       int
+	failSave    int
+	failDelete  string
+	saveDelay   time.Duration
+	inFlight    int
+	maxInFlight int
+}
+
+func (b *failingBackends) record(operation, resource string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.calls = append(b.calls, operation+" "+resource)
+	if operation == "save" {
+		b.saves++
+		if b.saves == b.failSave {
+			return fmt.Errorf("save %v failed", resource)
+		}
+		b.inFlight++
+		if b.inFlight > b.maxInFlight {
+			b.maxInFlight = b.inFlight
+		}
+	}
+	if operation == "delete" && resource == b.failDelete {
+		return fmt.Errorf("delete %v failed", resource)
+	}
+	return nil
+}
+
+// saved ends a save started by record, once the save delay is over
+func (b *failingBackends) saved() {
+	time.Sleep(b.saveDelay)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.inFlight--
+}
+
+type failingApplications struct {
+	spincli.ApplicationBackend
+	*failingBackends
+}
+
+func (a failingApplications) Save(ctx context.Context, appName string, spec interface{}) error {
+	if err := a.record("save", "Application/"+appName); err != nil {
+		return err
+	}
+	a.saved()
+	return a.ApplicationBackend.Save(ctx, appName, spec)
+}
+
+func (a failingApplications) Delete(ctx context.Context, appName string) error {
+	if err := a.record("delete", "Application/"+appName); err != nil {
+		return err
+	}
+	return a.ApplicationBackend.Delete(ctx, appName)
+}
+
+type failingPipelines struct {
+	spincli.PipelineBackend
+	*failingBackends
+}
+
+func (p failingPipelines) Save(ctx context.Context, appName, pipeName string, spec interface{}) error {
+	if err := p.record("save", "Pipeline/"+pipeName); err != nil {
+		return err
+	}
+	p.saved()
+	return p.PipelineBackend.Save(ctx, appName, pipeName, spec)
+}
+
+func (p failingPipelines) Delete(ctx context.Context, appName, pipeName string) error {
+	if err := p.record("delete", "Pipeline/"+pipeName); err != nil {
+		return err
+	}
+	return p.PipelineBackend.Delete(ctx, appName, pipeName)
+}
+
+// saveExisting saves the test application and its deploy pipeline before the apply, returns them as saved
+func saveExisting(t *testing.T, memory *spincli.Memory) ([]byte, []byte) {
+	ctx := context.Background()
+	if err := memory.Applications().Save(ctx, "test", map[string]interface{}{"name": "test", "email": "old@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := memory.Pipelines().Save(ctx, "test", "deploy", map[string]interface{}{"application": "test", "name": "deploy", "stages": []interface{}{}}); err != nil {
+		t.Fatal(err)
+	}
+	return getApplication(t, memory, "test"), getPipeline(t, memory, "test", "deploy")
+}
+
+// rollbackManifests updates the existing application and pipeline, creates an application and two pipelines, the last pipeline saved is broken
+func rollbackManifests(t *testing.T) []manifest.Manifest {
+	otherApplication := strings.Replace(testApplication, "name: test", "name: other", 1)
+	return decode(t, testApplication, otherApplication, testPipeline, pipelineDocument("test", "notify"), pipelineDocument("test", "broken"))
+}
+
+func checkRolledBack(t *testing.T, memory *spincli.Memory, existingApp, existingPipe []byte) {
+	if diff := deep.Equal(getApplication(t, memory, "test"), existingApp); diff != nil {
+		t.Error(diff)
+	}
+	if diff := deep.Equal(getPipeline(t, memory, "test", "deploy"), existingPipe); diff != nil {
+		t.Error(diff)
+	}
+	if app := getApplication(t, memory, "other"); len(app) != 0 {
+		t.Errorf("expected the created application deleted, got %s", app)
+	}
+	for _, pipeName := range []string{"notify", "broken"} {
+		if pipe := getPipeline(t, memory, "test", pipeName); len(pipe) != 0 {
+			t.Errorf("expected the created pipeline '%v' deleted, got %s", pipeName, pipe)
+		}
+	}
+}
+
+func pipelineDocument(appName, pipeName string) string {
+	return fmt.Sprintf(`
+apiVersion: spinnaker.adobe.com/alpha1
+kind: Pipeline
+metadata:
+  name: %v
+  application: %v
+spec:
+  stages:
+    - name: wait
+      type: wait
+      waitTime: 30
+`, pipeName, appName)
+}
+
+func decode(t *testing.T, documents ...string) []manifest.Manifest {
+	m := manifest.Manifest{}
+	buffer := new(bytes.Buffer)
+	for _, document := range documents {
+		buffer.WriteString("---" + document)
+	}
+	manifests, err := m.Decode(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manifests
+}
+
+func getApplication(t *testing.T, memory *spincli.Memory, appName string) []byte {
+	app, err := memory.Applications().Get(context.Background(), appName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func getPipeline(t *testing.T, memory *spincli.Memory, appName, pipeName string) []byte {
+	pipe, err := memory.Pipelines().Get(context.Background(), appName, pipeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pipe
+}
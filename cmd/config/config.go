@@ -19,7 +19,6 @@ import (
 	"github.com/mitchellh/go-homedir"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
-	"swinch/domain/datastore"
 )
 
 const (
@@ -28,30 +27,8 @@ const (
 
 	CfgFileName = "config.yaml"
 	CfgFilePerm = 0600
-
-	CfgSpinFileName = "context-spin-config.yaml"
-	CfgSpinFilePerm = 0600
 )
 
-// SpinConfigFile struct used to populate ~/.swinch/context-spin-config.yaml; this file is served to the spin-cli calls
-type SpinConfigFile struct {
-	Gate struct {
-		Endpoint string `yaml:"endpoint"`
-	} `yaml:"gate"`
-
-	Auth struct {
-		Enabled bool `yaml:"enabled"`
-		Ldap    struct {
-			Username string `yaml:"username"`
-			Password string `yaml:"password"`
-		} `yaml:"ldap,omitempty"`
-		Basic struct {
-			Username string `yaml:"username"`
-			Password string `yaml:"password"`
-		} `yaml:"basic,omitempty"`
-	} `yaml:"auth"`
-}
-
 // ContextDefinition struct used to populate ~/.swinch/config.yaml contexts
 type ContextDefinition struct {
 	Name     string
@@ -66,15 +43,21 @@ type CurrentContext struct {
 	Name string
 }
 
+// PassThroughStages struct used to populate ~/.swinch/config.yaml pass-through-stages
+// the stage types not modeled by swinch are passed through to Spinnaker when enabled, only the allowlist types if set
+type PassThroughStages struct {
+	Enabled   bool
+	Allowlist []string
+}
+
 type CPrompt struct {
 	PUI       promptui.Prompt
 	FieldName string
 }
 
-// GenerateSpinConfigFile method parses the ~/.swinch/config.yaml file, validates the current-context against all the available contexts
-// and creates/populates the ~/.swinch/context-spin-config.yaml file used by the spin-cli calls
-func (scf SpinConfigFile) GenerateSpinConfigFile() {
-	cd := ContextDefinition{}
+// GetCurrentContextDefinition method parses the ~/.swinch/config.yaml file and returns the current-context definition
+// the password is returned base64 decoded, ready to be used by the Gate client
+func (cd ContextDefinition) GetCurrentContextDefinition() (ContextDefinition, error) {
 	ctx, _ := cd.GetContexts()
 
 	cc := CurrentContext{}
@@ -82,23 +65,12 @@ func (scf SpinConfigFile) GenerateSpinConfigFile() {
 
 	for _, context := range ctx {
 		if context.Name == currentCtx {
-			scf.Gate.Endpoint = context.Endpoint
-			scf.Auth.Enabled = true
-
-			switch context.Auth {
-			case "ldap":
-				scf.Auth.Ldap.Username = context.Username
-				scf.Auth.Ldap.Password = Base64Decode(context.Password)
-			case "basic":
-				scf.Auth.Basic.Username = context.Username
-				scf.Auth.Basic.Password = Base64Decode(context.Password)
-			}
+			context.Password = Base64Decode(context.Password)
+			return context, nil
 		}
 	}
 
-	d := datastore.Datastore{}
-	spinCfgFile := d.MarshalYAML(&scf)
-	d.WriteFile(HomeFolder()+CfgFolderName+CfgSpinFileName, spinCfgFile, CfgSpinFilePerm)
+	return ContextDefinition{}, fmt.Errorf("curent context '%s' not found", currentCtx)
 }
 
 // GetContexts method parses the ~/.swinch/config.yaml file and returns the contexts
@@ -127,6 +99,20 @@ func (cc CurrentContext) GetCurrentContext() string {
 	return cc.Name
 }
 
+// GetPassThroughStages method parses the ~/.swinch/config.yaml file and returns the pass-through-stages definition
+func (pt PassThroughStages) GetPassThroughStages() (PassThroughStages, error) {
+	if err := viper.UnmarshalKey("pass-through-stages", &pt); err != nil {
+		return PassThroughStages{}, fmt.Errorf("error reading pass-through-stages: %w", err)
+	}
+	return pt, nil
+}
+
+// GetLenientStages function parses the ~/.swinch/config.yaml file and returns lenient-stages
+// the unknown keys of the stages without a strict key are passed through to Spinnaker when set
+func GetLenientStages() bool {
+	return viper.GetBool("lenient-stages")
+}
+
 // ValidateCurrentContext function validates that 'current-context' exists in the contexts list, and it is valid (all fields populated); returns bool type
 func (cd ContextDefinition) ValidateCurrentContext() error {
 	_, ctxList := cd.GetContexts()
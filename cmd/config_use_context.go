@@ -94,7 +94,4 @@ func changeCurrentContext(newContext string) {
 	} else {
 		log.Infof("Current context is now set to: '%s'", newContext)
 	}
-
-	scf := config.SpinConfigFile{}
-	scf.GenerateSpinConfigFile()
 }
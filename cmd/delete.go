@@ -13,8 +13,11 @@ governing permissions and limitations under the License.
 package cmd
 
 import (
+	"context"
 	"github.com/spf13/cobra"
+	"swinch/domain/application"
 	"swinch/domain/manifest"
+	"swinch/domain/pipeline"
 )
 
 // deleteCmd represents the delete command
@@ -27,39 +30,63 @@ var deleteCmd = &cobra.Command{
 		ValidateConfigFile()
 		ValidateConfig()
 	},
-	Run: func(cmd *cobra.Command, args []string) {
-		runDelete()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDelete(cmd.Context())
 	},
 }
 
 func init() {
-	deleteCmd.Flags().StringVarP(&filePath, "file", "f", "", "Manifest file")
+	deleteCmd.Flags().StringSliceVarP(&manifestPaths, "file", "f", nil, "Manifest files, directories or glob patterns, repeatable, - reads the manifests from stdin")
+	deleteCmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "Read the manifest directories recursively")
 	deleteCmd.MarkFlagRequired("file")
 	rootCmd.AddCommand(deleteCmd)
 }
 
-func Destroy(m manifest.M) {
-	m.Destroy()
+func Destroy(ctx context.Context, m manifest.M) error {
+	return m.Destroy(ctx)
 }
 
-func runDelete() {
+func runDelete(ctx context.Context) error {
 	m := manifest.NewManifest{}
-	manifests := m.GetManifests(filePath)
+	manifests, err := m.GetManifests(manifestPaths, recursive)
+	if err != nil {
+		return err
+	}
+	return deleteManifests(ctx, manifests)
+}
+
+// deleteManifests deletes the pipelines then the applications of the manifests
+func deleteManifests(ctx context.Context, manifests []manifest.Manifest) error {
+	options, err := stageOptions()
+	if err != nil {
+		return err
+	}
+	r := manifestRun{action: "delete"}
 
 	// Pipelines deletion should run before application deletion
 	for _, newManifest := range manifests {
-		switch newManifest.Kind {
-		case m.Pipeline.GetKind():
-			pipeline := m.Pipeline.Load(newManifest)
-			Destroy(pipeline)
+		if newManifest.Kind == pipeline.Kind {
+			r.run(newManifest.Name(), func() error {
+				resource, err := loadManifest(newManifest, options)
+				if err != nil {
+					return err
+				}
+				return Destroy(ctx, resource)
+			})
 		}
 	}
 
 	for _, newManifest := range manifests {
-		switch newManifest.Kind {
-		case m.Application.GetKind():
-			application := m.Application.Load(newManifest)
-			Destroy(application)
+		if newManifest.Kind == application.Kind {
+			r.run(newManifest.Name(), func() error {
+				resource, err := loadManifest(newManifest, options)
+				if err != nil {
+					return err
+				}
+				return Destroy(ctx, resource)
+			})
 		}
 	}
+
+	return r.summary()
 }
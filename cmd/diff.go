@@ -0,0 +1,134 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strings"
+	"swinch/domain/change"
+	"swinch/domain/manifest"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [old-manifests new-manifests]",
+	Short: "Diff the Spinnaker specs generated from two charts, values files or manifest directories",
+	Long: `Diff the Spinnaker specs generated from two charts, values files or manifest directories, offline without Spinnaker.
+The charts are rendered and the pipeline stages expanded, the changes are printed by application and pipeline:
+
+  swinch diff -c chart --values old.yaml --values-new new.yaml
+  swinch diff old-manifests new-manifests`,
+	Args: cobra.RangeArgs(0, 2),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		SetLogLevel(logLevel)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDiff(args)
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&chartPath, "chart", "c", "", "Dir path for the old chart")
+	diffCmd.Flags().StringVarP(&chartNewPath, "chart-new", "", "", "Dir path for the new chart, defaults to --chart")
+	diffCmd.Flags().StringVarP(&valuesFilePath, "values", "f", "", "Overwrite the old chart values file")
+	diffCmd.Flags().StringVarP(&valuesNewFilePath, "values-new", "", "", "Overwrite the new chart values file, defaults to --values")
+	diffCmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "Read the manifest directories recursively")
+	diffCmd.Flags().StringVarP(&outputFormat, "output", "o", "", fmt.Sprintf("Print the diff in a machine readable format: %v", strings.Join(change.Formats, "|")))
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(args []string) error {
+	if outputFormat != "" {
+		if err := validateFormat(outputFormat); err != nil {
+			return err
+		}
+	}
+
+	var oldManifests, newManifests []manifest.Manifest
+	var err error
+	switch {
+	case len(args) == 2 && chartPath == "":
+		m := manifest.Manifest{}
+		if oldManifests, err = m.GetManifests([]string{args[0]}, recursive); err != nil {
+			return err
+		}
+		if newManifests, err = m.GetManifests([]string{args[1]}, recursive); err != nil {
+			return err
+		}
+	case len(args) == 0 && chartPath != "":
+		newChartPath, newValuesFile := chartNewPath, valuesNewFilePath
+		if newChartPath == "" {
+			newChartPath = chartPath
+		}
+		if newValuesFile == "" {
+			newValuesFile = valuesFilePath
+		}
+		if newChartPath == chartPath && newValuesFile == valuesFilePath {
+			return fmt.Errorf("nothing to compare, set --values-new or --chart-new")
+		}
+		if oldManifests, err = renderManifests(chartPath, valuesFilePath); err != nil {
+			return err
+		}
+		if newManifests, err = renderManifests(newChartPath, newValuesFile); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("diff either a chart with --chart or two manifest directories")
+	}
+
+	options, err := stageOptions()
+	if err != nil {
+		return err
+	}
+	p, err := manifest.Diff(oldManifests, newManifests, options)
+	if err != nil {
+		return err
+	}
+	if outputFormat != "" {
+		return p.Write(os.Stdout, outputFormat)
+	}
+	writeDiff(os.Stdout, p)
+	log.Infof("Diff: %v", p.Summary())
+	return nil
+}
+
+// renderManifests renders a chart in memory and decodes its manifests, the stages are expanded when the manifests are loaded
+func renderManifests(chartPath, valuesFile string) ([]manifest.Manifest, error) {
+	t, err := newTemplate()
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := renderChart(t, chartPath, valuesFile, false, false)
+	if err != nil {
+		return nil, err
+	}
+	m := manifest.Manifest{}
+	return m.Decode(rendered)
+}
+
+// writeDiff prints the changed applications and pipelines with their changed fields
+func writeDiff(w io.Writer, p change.Plan) {
+	for _, c := range p.Changes {
+		if c.Action == change.NoOp {
+			continue
+		}
+		fmt.Fprintf(w, "%v %v/%v: %v\n", c.Kind, c.Application, c.Name, c.Action)
+		for _, field := range c.Fields {
+			fmt.Fprintf(w, "  %v\n", field)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+	"swinch/domain/change"
+	"time"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history <release>",
+	Short: "Lists the revisions of a release",
+	Long:  "Lists the revisions of a release, recorded by install, upgrade and rollback",
+	Args:  cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		SetLogLevel(logLevel)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHistory(args[0])
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVarP(&outputFormat, "output", "o", "", fmt.Sprintf("Output format: %v, defaults to table", strings.Join(change.Formats, "|")))
+	historyCmd.Flags().StringVarP(&stateDir, "state-dir", "", "", "Release state directory, defaults to ~/.swinch/releases")
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(releaseName string) error {
+	if outputFormat == "" {
+		outputFormat = change.TableFormat
+	}
+	if err := validateFormat(outputFormat); err != nil {
+		return err
+	}
+	history, err := releaseStore().History(releaseName)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		return fmt.Errorf("release '%v' not found", releaseName)
+	}
+
+	switch outputFormat {
+	case change.JSONFormat:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", " ")
+		return encoder.Encode(history)
+	case change.YAMLFormat:
+		// the values and manifests are left out, like the json output
+		for i := range history {
+			history[i].Values = nil
+			history[i].Manifests = ""
+		}
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		return encoder.Encode(history)
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"REVISION", "UPDATED", "STATUS", "CHART", "CHART-VERSION", "DESCRIPTION"})
+	for _, revision := range history {
+		t.AppendRow(table.Row{revision.Number, revision.Updated.Format(time.RFC1123), revision.Status, revision.Chart, revision.ChartVersion, revision.Description})
+	}
+	t.SetStyle(table.Style{
+		Name: "swinch",
+		Box: table.BoxStyle{
+			PaddingLeft:  "",
+			PaddingRight: "     ",
+		},
+	})
+	t.Render()
+	return nil
+}
@@ -13,7 +13,11 @@ governing permissions and limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"github.com/spf13/cobra"
+	"path"
+	"swinch/domain/chart"
+	"swinch/domain/datastore"
 )
 
 // importCmd represents the import command
@@ -35,3 +39,62 @@ var importCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(importCmd)
 }
+
+// importPreRun validates the config only for imports from Spinnaker, imports from files run offline
+func importPreRun(cmd *cobra.Command, args []string) {
+	SetLogLevel(logLevel)
+	if filePath == "" {
+		ValidateConfigFile()
+		ValidateConfig()
+	}
+}
+
+// importFiles returns the Spinnaker JSON files to import, if the file flag is set
+func importFiles() ([][]byte, bool, error) {
+	if filePath == "" {
+		return nil, false, nil
+	}
+	d := datastore.Datastore{}
+	files, err := d.ReadJSONFiles(filePath)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(files) > 1 && chartName != "" && !importAll {
+		return nil, false, errors.New("the chart name can be set only when importing a single file")
+	}
+	return files, true, nil
+}
+
+// importChartName returns the chart name flag, or a chart name derived from the imported object name
+func importChartName(name string) string {
+	if chartName != "" {
+		return chartName
+	}
+	return chart.FileName(name)
+}
+
+// writeImportChart writes the imported manifests as chart templates, the literals matching the value rules are extracted as chart values
+// returns the chart path
+func writeImportChart(name, kind string, manifests map[string]interface{}) (string, error) {
+	rules, err := chart.LoadValueRules(valueRulesPath)
+	if err != nil {
+		return "", err
+	}
+	e := chart.Extractor{Rules: rules}
+	values, templates, err := e.ExtractValues(manifests)
+	if err != nil {
+		return "", err
+	}
+	c := chart.Chart{
+		OutputPath:      outputPath,
+		Kind:            kind,
+		ProtectedImport: protectedImport,
+		Metadata:        chart.Metadata{Name: importChartName(name)},
+		Values:          values,
+	}
+	err = c.GenerateChartTemplates(templates)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(outputPath, c.Metadata.Name), nil
+}
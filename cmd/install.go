@@ -13,38 +13,75 @@ governing permissions and limitations under the License.
 package cmd
 
 import (
+	"context"
 	"github.com/spf13/cobra"
-	"os"
-	"swinch/domain/datastore"
+	"swinch/domain/release"
 )
 
 // installCmd represents the install command
 var installCmd = &cobra.Command{
-	Use:   "install",
-	Short: "Installs a swinch chart",
-	Long:  `Installs a swinch chart.`,
+	Use:     "install [release]",
+	Aliases: []string{"upgrade"},
+	Short:   "Installs or upgrades a swinch chart release",
+	Long: `Installs or upgrades a swinch chart release.
+Each install records a release revision with the rendered manifests, the release name defaults to the chart name.`,
+	Args: cobra.MaximumNArgs(1),
 	PreRun: func(cmd *cobra.Command, args []string) {
 		SetLogLevel(logLevel)
 		ValidateConfigFile()
 		ValidateConfig()
 	},
-	Run: func(cmd *cobra.Command, args []string) {
-		// Template call
-		d := datastore.Datastore{}
-		outputPath = d.CreateTmpFolder()
-		defer os.RemoveAll(outputPath)
-		templateCmd.Run(cmd, []string{})
-
-		// Apply call
-		filePath = outputPath
-		applyCmd.Run(cmd, []string{})
+	RunE: func(cmd *cobra.Command, args []string) error {
+		releaseName := ""
+		if len(args) > 0 {
+			releaseName = args[0]
+		}
+		return runInstall(cmd.Context(), releaseName)
 	},
 }
 
 func init() {
 	installCmd.Flags().StringVarP(&chartPath, "chart", "c", "", "Dir path for chart")
 	installCmd.Flags().StringVarP(&valuesFilePath, "values", "f", "", "Overwrite chart values file")
-	installCmd.Flags().BoolVarP(&plan, "plan", "p", true, "Display plan while installing, no user input.")
+	installCmd.Flags().BoolVarP(&plan, "plan", "p", true, "Display the plan changes before the confirmation")
+	installCmd.Flags().BoolVarP(&autoApprove, "auto-approve", "", false, "Apply the plan without confirmation")
+	installCmd.Flags().IntVarP(&parallelism, "parallelism", "", 1, "Number of manifests planned and pipelines of an application saved concurrently")
+	installCmd.Flags().StringVarP(&stateDir, "state-dir", "", "", "Release state directory, defaults to ~/.swinch/releases")
 	installCmd.MarkFlagRequired("chart")
 	rootCmd.AddCommand(installCmd)
 }
+
+func runInstall(ctx context.Context, releaseName string) error {
+	t, err := newTemplate()
+	if err != nil {
+		return err
+	}
+	rendered, err := renderChart(t, chartPath, valuesFilePath, false, false)
+	if err != nil {
+		return err
+	}
+
+	metadata, releaseName, err := chartRelease(chartPath, releaseName)
+	if err != nil {
+		return err
+	}
+
+	history, err := releaseStore().History(releaseName)
+	if err != nil {
+		return err
+	}
+	// an uninstalled release is installed again
+	description := "Install"
+	if len(history) > 0 && history[len(history)-1].Status != release.Uninstalled {
+		description = "Upgrade"
+	}
+
+	return applyRevision(ctx, release.Revision{
+		Release:      releaseName,
+		Description:  description,
+		Chart:        metadata.Name,
+		ChartVersion: metadata.Version,
+		Values:       t.Values.Values,
+		Manifests:    rendered.String(),
+	})
+}
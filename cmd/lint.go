@@ -0,0 +1,85 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"swinch/domain/lint"
+	"swinch/domain/manifest"
+	"swinch/domain/schema"
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint [manifests]",
+	Short: "Lint a chart or manifests offline",
+	Long: `Lint a chart or manifests offline, every problem found is reported at once.
+The chart is rendered in memory, the command fails if any problem is found:
+
+  swinch lint -c chart -f values.yaml
+  swinch lint -R manifests`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		SetLogLevel(logLevel)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLint(args)
+	},
+}
+
+func init() {
+	lintCmd.Flags().StringVarP(&chartPath, "chart", "c", "", "Dir path for chart")
+	lintCmd.Flags().StringVarP(&valuesFilePath, "values", "f", "", "Overwrite chart values file")
+	lintCmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "Read the manifest directories recursively")
+	lintCmd.Flags().StringVarP(&lintFormat, "format", "", lint.TextFormat, fmt.Sprintf("Findings format: %v", strings.Join(lint.Formats, "|")))
+	rootCmd.AddCommand(lintCmd)
+}
+
+func runLint(args []string) error {
+	var manifests []manifest.Manifest
+	var err error
+	switch {
+	case chartPath != "" && len(args) == 0:
+		manifests, err = renderManifests(chartPath, valuesFilePath)
+	case chartPath == "" && len(args) > 0:
+		m := manifest.Manifest{}
+		manifests, err = m.GetManifests(args, recursive)
+	default:
+		return fmt.Errorf("lint either a chart with --chart or manifests")
+	}
+	invalid := &schema.ValidationError{}
+	if errors.As(err, &invalid) {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	options, err := stageOptions()
+	if err != nil {
+		return err
+	}
+	findings := lint.Lint(manifests, invalid, options)
+	if err = lint.Write(os.Stdout, findings, lintFormat); err != nil {
+		return err
+	}
+	if len(findings) > 0 {
+		return fmt.Errorf("%d problems found in %d manifests", len(findings), len(manifests))
+	}
+	log.Infof("No problems found in %d manifests", len(manifests))
+	return nil
+}
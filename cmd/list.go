@@ -0,0 +1,103 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+	"swinch/domain/change"
+	"swinch/domain/owner"
+	"swinch/domain/pipeline"
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the pipelines of an application managed by swinch",
+	Long:  "List the pipelines of an application managed by swinch, with the chart, the values and the swinch version which saved them",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		SetLogLevel(logLevel)
+		ValidateConfigFile()
+		ValidateConfig()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runList(cmd.Context())
+	},
+}
+
+func init() {
+	listCmd.Flags().StringVarP(&applicationName, "application", "a", "", "Application name")
+	listCmd.Flags().StringVarP(&outputFormat, "output", "o", "", fmt.Sprintf("Output format: %v, defaults to table", strings.Join(change.Formats, "|")))
+	listCmd.MarkFlagRequired("application")
+	rootCmd.AddCommand(listCmd)
+}
+
+type ownedPipeline struct {
+	Application string       `yaml:"application" json:"application"`
+	Name        string       `yaml:"name" json:"name"`
+	Owner       *owner.Owner `yaml:"owner" json:"owner"`
+}
+
+func runList(ctx context.Context) error {
+	if outputFormat == "" {
+		outputFormat = change.TableFormat
+	}
+	if err := validateFormat(outputFormat); err != nil {
+		return err
+	}
+
+	p := pipeline.Pipeline{}
+	specs, err := p.List(ctx, applicationName)
+	if err != nil {
+		return err
+	}
+	owned := make([]ownedPipeline, 0)
+	for _, spec := range specs {
+		if spec.ManagedBy != nil {
+			owned = append(owned, ownedPipeline{Application: applicationName, Name: spec.Name, Owner: spec.ManagedBy})
+		}
+	}
+
+	switch outputFormat {
+	case change.JSONFormat:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", " ")
+		return encoder.Encode(owned)
+	case change.YAMLFormat:
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		return encoder.Encode(owned)
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"NAME", "CHART", "CHART-VERSION", "VALUES-HASH", "SWINCH-VERSION"})
+	for _, o := range owned {
+		t.AppendRow(table.Row{o.Name, o.Owner.Chart, o.Owner.ChartVersion, o.Owner.ValuesHash, o.Owner.Version})
+	}
+	t.SetStyle(table.Style{
+		Name: "swinch",
+		Box: table.BoxStyle{
+			PaddingLeft:  "",
+			PaddingRight: "     ",
+		},
+	})
+	t.Render()
+	return nil
+}
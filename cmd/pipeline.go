@@ -13,9 +13,13 @@ governing permissions and limitations under the License.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"strings"
+	"swinch/domain/chart"
 	"swinch/domain/pipeline"
 )
 
@@ -29,9 +33,9 @@ var pipelineCmd = &cobra.Command{
 		ValidateConfigFile()
 		ValidateConfig()
 	},
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		subCmd := cmd.Parent().Use
-		cmdPipeAction(subCmd)
+		return cmdPipeAction(cmd.Context(), subCmd)
 	},
 }
 
@@ -43,13 +47,14 @@ func init() {
 	// import flags
 	ImportPipeCmd.Flags().StringVarP(&applicationName, "application", "a", "", "Application name")
 	ImportPipeCmd.Flags().StringVarP(&pipelineName, "pipeline", "p", "", "Pipeline name")
-	ImportPipeCmd.Flags().StringVarP(&filePath, "file", "f", "", "JSON file input")
+	ImportPipeCmd.Flags().StringVarP(&filePath, "file", "f", "", "Import from Spinnaker JSON file or directory, non recursive")
 	ImportPipeCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Generated chart output path")
 	ImportPipeCmd.Flags().StringVarP(&chartName, "chart", "n", "", "Specify chart name for imported pipeline")
 	ImportPipeCmd.Flags().BoolVarP(&protectedImport, "protected-import", "", false, "Protect already created chart from overwriting")
-	ImportPipeCmd.MarkFlagRequired("application")
-	ImportPipeCmd.MarkFlagRequired("pipeline")
+	ImportPipeCmd.Flags().BoolVarP(&importAll, "all", "", false, "Import all the application pipelines in one chart")
+	ImportPipeCmd.Flags().StringVarP(&valueRulesPath, "value-rules", "", "", "Yaml file with the rules extracting chart values, overrides the default rules")
 	ImportPipeCmd.MarkFlagRequired("output")
+	ImportPipeCmd.PreRun = importPreRun
 	importCmd.AddCommand(&ImportPipeCmd)
 
 	// delete flags
@@ -63,14 +68,93 @@ func init() {
 	planCmd.AddCommand(&PlanPipeCmd)
 }
 
-func cmdPipeAction(subCmd string) {
+func cmdPipeAction(ctx context.Context, subCmd string) error {
 	p := pipeline.Pipeline{}
 	switch subCmd {
 	case deleteAction:
-		p.Delete(applicationName, pipelineName)
+		p.Metadata.Application = applicationName
+		p.Metadata.Name = pipelineName
+		return p.Destroy(ctx)
 	case importAction:
-		fmt.Println("Import TBA")
+		return importPipeline(ctx, p)
 	default:
-		log.Fatalf("Unknown pipeline command")
+		return errors.New("unknown pipeline command")
 	}
 }
+
+func importPipeline(ctx context.Context, p pipeline.Pipeline) error {
+	manifests := make([]pipeline.Manifest, 0)
+	files, ok, err := importFiles()
+	if err != nil {
+		return err
+	}
+	if p.StageOptions, err = stageOptions(); err != nil {
+		return err
+	}
+	if ok {
+		for _, pipeJSON := range files {
+			manifest, err := p.ImportJSON(pipeJSON)
+			if err != nil {
+				return err
+			}
+			manifests = append(manifests, manifest)
+		}
+	} else if importAll {
+		if applicationName == "" {
+			return errors.New("required flag(s) \"application\" or \"file\" not set")
+		}
+		manifests, err = p.ImportAll(ctx, applicationName)
+		if err != nil {
+			return err
+		}
+	} else {
+		if applicationName == "" || pipelineName == "" {
+			return errors.New("required flag(s) \"application\" and \"pipeline\", or \"file\" not set")
+		}
+		manifest, err := p.Import(ctx, applicationName, pipelineName)
+		if err != nil {
+			return err
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	if importAll {
+		return writePipelinesChart(manifests)
+	}
+	for _, manifest := range manifests {
+		err = writePipelineChart(manifest)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePipelinesChart writes all the pipelines in one chart, with a template for each pipeline
+func writePipelinesChart(manifests []pipeline.Manifest) error {
+	manifestTemplates := make(map[string]interface{})
+	for _, manifest := range manifests {
+		name := chart.FileName(manifest.Metadata.Name)
+		for i := 2; manifestTemplates[name] != nil; i++ {
+			name = fmt.Sprintf("%v-%v", chart.FileName(manifest.Metadata.Name), i)
+		}
+		manifestTemplates[name] = manifest
+	}
+
+	chartPath, err := writeImportChart(manifests[0].Metadata.Application, strings.ToLower(pipeline.Kind), manifestTemplates)
+	if err != nil {
+		return err
+	}
+	log.Infof("%v pipelines imported in chart '%v'", len(manifests), chartPath)
+	return nil
+}
+
+func writePipelineChart(manifest pipeline.Manifest) error {
+	kind := strings.ToLower(pipeline.Kind)
+	chartPath, err := writeImportChart(manifest.Metadata.Name, kind, map[string]interface{}{kind: manifest})
+	if err != nil {
+		return err
+	}
+	log.Infof("Pipeline '%v' imported in chart '%v'", manifest.Metadata.Name, chartPath)
+	return nil
+}
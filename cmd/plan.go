@@ -13,7 +13,12 @@ governing permissions and limitations under the License.
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"swinch/domain/change"
 	"swinch/domain/manifest"
 )
 
@@ -27,30 +32,93 @@ var planCmd = &cobra.Command{
 		ValidateConfigFile()
 		ValidateConfig()
 	},
-	Run: func(cmd *cobra.Command, args []string) {
-		runPlan()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPlan(cmd.Context())
 	},
 }
 
 func init() {
-	planCmd.Flags().StringVarP(&filePath, "file", "f", "", "Manifest file or directory, non recursive")
+	planCmd.Flags().StringSliceVarP(&manifestPaths, "file", "f", nil, "Manifest files, directories or glob patterns, repeatable, - reads the manifests from stdin")
+	planCmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "Read the manifest directories recursively")
 	planCmd.MarkFlagRequired("file")
+	planCmd.Flags().StringVarP(&outputFormat, "output", "o", "", fmt.Sprintf("Print the plan to stdout in a machine readable format: %v", strings.Join(change.Formats, "|")))
 	rootCmd.AddCommand(planCmd)
 }
 
-func Plan(m manifest.M) {
-	m.Plan()
+func Plan(ctx context.Context, m manifest.M) error {
+	return m.Plan(ctx)
 }
 
-func runPlan() {
+func runPlan(ctx context.Context) error {
+	if outputFormat != "" {
+		return writePlan(ctx, outputFormat)
+	}
+
+	m := manifest.NewManifest{}
+	manifests, err := m.GetManifests(manifestPaths, recursive)
+	if err != nil {
+		return err
+	}
+	options, err := stageOptions()
+	if err != nil {
+		return err
+	}
+	r := manifestRun{action: "plan"}
+	for _, newManifest := range manifests {
+		r.run(newManifest.Name(), func() error {
+			resource, err := loadManifest(newManifest, options)
+			if err != nil {
+				return err
+			}
+			return Plan(ctx, resource)
+		})
+	}
+	return r.summary()
+}
+
+// writePlan builds the plan of every manifest and prints it, the manifests failing to plan are left out of the plan
+func writePlan(ctx context.Context, format string) error {
+	if err := validateFormat(format); err != nil {
+		return err
+	}
+
 	m := manifest.NewManifest{}
-	manifests := m.GetManifests(filePath)
+	manifests, err := m.GetManifests(manifestPaths, recursive)
+	if err != nil {
+		return err
+	}
+	options, err := stageOptions()
+	if err != nil {
+		return err
+	}
+	p := change.Plan{}
+	r := manifestRun{action: "plan"}
 	for _, newManifest := range manifests {
-		switch newManifest.Kind {
-		case m.Application.GetKind():
-			Plan(m.Application.Load(newManifest))
-		case m.Pipeline.GetKind():
-			Plan(m.Pipeline.Load(newManifest))
+		r.run(newManifest.Name(), func() error {
+			resource, err := loadManifest(newManifest, options)
+			if err != nil {
+				return err
+			}
+			c, err := resource.Change(ctx)
+			if err != nil {
+				return err
+			}
+			p.Add(c)
+			return nil
+		})
+	}
+
+	if err = p.Write(os.Stdout, format); err != nil {
+		return err
+	}
+	return r.summary()
+}
+
+func validateFormat(format string) error {
+	for _, f := range change.Formats {
+		if f == format {
+			return nil
 		}
 	}
+	return fmt.Errorf("unknown output format '%v', supported formats: %v", format, strings.Join(change.Formats, ", "))
 }
@@ -0,0 +1,44 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"swinch/domain/pipeline"
+)
+
+// planPrune plans the deletion of the pipelines of the applied applications owned by the applied charts and missing from the manifests
+// managed maps each application to the names of its applied pipelines
+func planPrune(ctx context.Context, managed map[string]map[string]bool, charts map[string]bool) ([]plannedChange, error) {
+	if len(charts) == 0 {
+		log.Warnf("Prune skipped, the applied pipelines are not rendered from a chart")
+		return nil, nil
+	}
+
+	p := pipeline.Pipeline{Backend: pipelineBackend}
+	deletions := make([]plannedChange, 0)
+	for _, appName := range sortedNames(managed) {
+		changes, err := p.Unmanaged(ctx, appName, managed[appName], charts, pruneAllowlist)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the unmanaged pipelines of application '%v': %w", appName, err)
+		}
+		for _, c := range changes {
+			pipe := &pipeline.Pipeline{Backend: pipelineBackend}
+			pipe.Metadata = pipeline.Metadata{Name: c.Name, Application: c.Application}
+			deletions = append(deletions, plannedChange{change: c, save: pipe.Destroy, resource: pipe})
+		}
+	}
+	return deletions, nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"path"
+	"path/filepath"
+	"swinch/cmd/config"
+	"swinch/domain/chart"
+	"swinch/domain/datastore"
+	"swinch/domain/manifest"
+	"swinch/domain/release"
+)
+
+// releaseStore returns the release state store, in ~/.swinch/releases if no state dir is set
+func releaseStore() release.Store {
+	if stateDir == "" {
+		return release.Store{Path: filepath.Join(config.HomeFolder(), config.CfgFolderName, "releases")}
+	}
+	return release.Store{Path: stateDir}
+}
+
+// chartRelease returns the chart metadata and the release name, the release name defaults to the chart name
+func chartRelease(chartPath, releaseName string) (chart.Metadata, string, error) {
+	metadata := chart.Metadata{}
+	d := datastore.Datastore{}
+	if d.FileExists(path.Join(chartPath, chart.MetadataFile)) {
+		var err error
+		if metadata, err = chart.LoadMetadata(chartPath); err != nil {
+			return chart.Metadata{}, "", err
+		}
+	}
+	if releaseName == "" {
+		releaseName = metadata.Name
+	}
+	return metadata, releaseName, release.ValidateName(releaseName)
+}
+
+// applyRevision applies the revision manifests and records the revision, failed if the apply failed
+// the revision description is the action, like Install, completed with the apply result
+func applyRevision(ctx context.Context, revision release.Revision) error {
+	m := manifest.Manifest{}
+	manifests, err := m.Decode(bytes.NewBufferString(revision.Manifests))
+	if err != nil {
+		return err
+	}
+
+	applyErr := applyManifests(ctx, manifests)
+	if errors.Is(applyErr, errApplyCancelled) {
+		return applyErr
+	}
+	revision.Status = release.Deployed
+	return recordRevision(revision, applyErr)
+}
+
+// recordRevision records the revision with the result of its action, failed if the action failed
+// the revision description is the action, like Install, completed with the result
+func recordRevision(revision release.Revision, actionErr error) error {
+	action := revision.Description
+	revision.Description = fmt.Sprintf("%v complete", action)
+	if actionErr != nil {
+		revision.Status = release.Failed
+		revision.Description = fmt.Sprintf("%v failed: %v", action, actionErr)
+	}
+
+	recorded, err := releaseStore().Record(revision)
+	if err != nil {
+		if actionErr != nil {
+			log.Errorf("Failed to record release '%v': %v", revision.Release, err)
+			return actionErr
+		}
+		return fmt.Errorf("failed to record release '%v': %w", revision.Release, err)
+	}
+	log.Infof("Release '%v' revision %d %v", recorded.Release, recorded.Number, recorded.Status)
+	return actionErr
+}
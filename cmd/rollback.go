@@ -0,0 +1,65 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"strconv"
+	"swinch/domain/release"
+	"time"
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <release> <revision>",
+	Short: "Rolls back a release to a previous revision",
+	Long:  "Rolls back a release to a previous revision, the revision manifests are applied again and recorded as a new revision",
+	Args:  cobra.ExactArgs(2),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		SetLogLevel(logLevel)
+		ValidateConfigFile()
+		ValidateConfig()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		revision, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid revision '%v': %w", args[1], err)
+		}
+		return runRollback(cmd.Context(), args[0], revision)
+	},
+}
+
+func init() {
+	rollbackCmd.Flags().BoolVarP(&plan, "plan", "p", true, "Display the plan changes before the confirmation")
+	rollbackCmd.Flags().BoolVarP(&prunePipelines, "prune", "", false, "Delete the pipelines owned by the release chart missing from the revision")
+	rollbackCmd.Flags().StringSliceVarP(&pruneAllowlist, "prune-allowlist", "", nil, "Pipelines never pruned, accepts name patterns like 'manual-*'")
+	rollbackCmd.Flags().BoolVarP(&autoApprove, "auto-approve", "", false, "Apply the plan without confirmation")
+	rollbackCmd.Flags().IntVarP(&parallelism, "parallelism", "", 1, "Number of manifests planned and pipelines of an application saved concurrently")
+	rollbackCmd.Flags().StringVarP(&stateDir, "state-dir", "", "", "Release state directory, defaults to ~/.swinch/releases")
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+func runRollback(ctx context.Context, releaseName string, number int) error {
+	revision, err := releaseStore().Get(releaseName, number)
+	if err != nil {
+		return err
+	}
+	if revision.Status == release.Uninstalled {
+		return fmt.Errorf("revision %d of release '%v' is an uninstall, roll back to a revision with manifests", number, releaseName)
+	}
+	revision.Description = fmt.Sprintf("Rollback to %d", number)
+	revision.Updated = time.Time{}
+	return applyRevision(ctx, revision)
+}
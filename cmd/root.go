@@ -13,27 +13,42 @@ governing permissions and limitations under the License.
 package cmd
 
 import (
+	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"os"
 	"swinch/cmd/config"
 	"swinch/domain/datastore"
+	"swinch/domain/stages"
 )
 
 var (
 	logLevel             string
 	plan                 bool
 	filePath             string
+	manifestPaths        []string
+	recursive            bool
 	outputPath           string
 	valuesFilePath       string
+	valuesNewFilePath    string
 	chartName            string
 	protectedImport      bool
+	importAll            bool
+	valueRulesPath       string
 	applicationName      string
 	pipelineName         string
 	chartPath            string
+	chartNewPath         string
 	fullRender           bool
 	excludeDefaultValues bool
+	outputFormat         string
+	lintFormat           string
+	prunePipelines       bool
+	pruneAllowlist       []string
+	autoApprove          bool
+	stateDir             string
+	parallelism          int
 )
 
 const (
@@ -44,6 +59,9 @@ const (
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use: "swinch",
+	// Errors are reported once by Execute, without the command usage
+	SilenceErrors: true,
+	SilenceUsage:  true,
 
 	Short: "Generate Spinnaker applications and pipelines from a kubernetes like objects",
 	Long:  "Generate Spinnaker applications and pipelines from a kubernetes like objects",
@@ -103,3 +121,23 @@ func ValidateConfig() {
 	}
 	log.Debugf("Using config file: '%s' with current-context as '%s'", viper.ConfigFileUsed(), viper.Get("current-context.name"))
 }
+
+// stageOptions returns the stage processing options set in the config file, the offline commands run with the defaults without a config file
+func stageOptions() (stages.ProcessOptions, error) {
+	d := datastore.Datastore{}
+	if !d.FileExists(config.HomeFolder() + config.CfgFolderName + config.CfgFileName) {
+		return stages.ProcessOptions{}, nil
+	}
+	if err := viper.ReadInConfig(); err != nil {
+		return stages.ProcessOptions{}, fmt.Errorf("a parsing error detected in '%s': %w", viper.ConfigFileUsed(), err)
+	}
+	pt := config.PassThroughStages{}
+	passThrough, err := pt.GetPassThroughStages()
+	if err != nil {
+		return stages.ProcessOptions{}, err
+	}
+	return stages.ProcessOptions{
+		PassThrough: stages.PassThroughConfig{Enabled: passThrough.Enabled, Allowlist: passThrough.Allowlist},
+		Lenient:     config.GetLenientStages(),
+	}, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"sync"
+)
+
+// manifestRun runs an action on each manifest, a failing manifest is reported and the run continues with the next one
+type manifestRun struct {
+	action string
+	total  int
+	failed []string
+}
+
+// run runs the action of a manifest, name is the manifest kind and name
+func (r *manifestRun) run(name string, action func() error) {
+	r.total++
+	err := action()
+	if err != nil {
+		log.Errorf("Failed to %v %v: %v", r.action, name, err)
+		r.failed = append(r.failed, name)
+	}
+}
+
+// summary reports the failed manifests, returns an error if any manifest failed
+func (r manifestRun) summary() error {
+	if len(r.failed) == 0 {
+		return nil
+	}
+	log.Errorf("%v of %v manifests failed to %v:", len(r.failed), r.total, r.action)
+	for _, name := range r.failed {
+		log.Errorf("  %v", name)
+	}
+	return fmt.Errorf("%v failed for %v of %v manifests", r.action, len(r.failed), r.total)
+}
+
+// runParallel calls fn for the indexes from 0 to count with at most parallelism concurrent calls
+// no call starts once a call failed, returns the first error
+func runParallel(parallelism, count int, fn func(i int) error) error {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		firstErr error
+	)
+	slots := make(chan struct{}, parallelism)
+	for i := 0; i < count; i++ {
+		slots <- struct{}{}
+		mutex.Lock()
+		stop := firstErr != nil
+		mutex.Unlock()
+		if stop {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := fn(i); err != nil {
+				mutex.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mutex.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "JSON Schemas of the swinch manifests",
+	Long:  `JSON Schemas of the swinch manifests, generated from the manifest and stage definitions`,
+	Example: `Export the schemas for yaml-language-server autocompletion:
+	swinch schema export -o schemas
+	# yaml-language-server: $schema=schemas/manifest.json`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		SetLogLevel(logLevel)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
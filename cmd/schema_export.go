@@ -0,0 +1,74 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"swinch/domain/datastore"
+	"swinch/domain/schema"
+)
+
+// schemaExportCmd represents the schema export command
+var schemaExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the JSON Schemas of the manifests",
+	Long: `Export the JSON Schemas of the manifests, one schema for each kind and manifest.json for all the kinds.
+Without --output the manifest.json schema is printed to stdout.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		SetLogLevel(logLevel)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return exportSchemas()
+	},
+}
+
+func init() {
+	schemaExportCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Dir path for writing the schemas, printed to stdout if not set")
+	schemaCmd.AddCommand(schemaExportCmd)
+}
+
+func exportSchemas() error {
+	if outputPath == "" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", " ")
+		return encoder.Encode(schema.Manifest())
+	}
+
+	d := datastore.Datastore{}
+	if err := d.Mkdir(outputPath, datastore.FilePerm); err != nil {
+		return err
+	}
+	schemas := map[string]*schema.Schema{"manifest": schema.Manifest()}
+	for kind, kindSchema := range schema.Kinds() {
+		schemas[strings.ToLower(kind)] = kindSchema
+	}
+	names := make([]string, 0)
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schemaPath := path.Join(outputPath, name+".json")
+		if err := d.WriteJSON(schemas[name], schemaPath); err != nil {
+			return err
+		}
+		log.Infof("Schema written in '%v'", schemaPath)
+	}
+	return nil
+}
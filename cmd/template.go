@@ -13,7 +13,9 @@ governing permissions and limitations under the License.
 package cmd
 
 import (
+	"bytes"
 	"github.com/spf13/cobra"
+	"os"
 	"swinch/domain/chart"
 )
 
@@ -21,29 +23,62 @@ import (
 var templateCmd = &cobra.Command{
 	Use:   "template",
 	Short: "Generate manifests from chart domain",
-	Long:  "Template command will generate the Spinnaker Application manifest from the chart domain",
+	Long: `Template command will generate the Spinnaker Application manifest from the chart domain.
+Without --output the manifests are printed to stdout as a multi document yaml, to be piped in plan or apply with -f -.`,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		SetLogLevel(logLevel)
 		ValidateConfigFile()
 		ValidateConfig()
 	},
-	Run: func(cmd *cobra.Command, args []string) {
-		Template()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return Template()
 	},
 }
 
 func init() {
 	templateCmd.Flags().StringVarP(&chartPath, "chart", "c", "", "Dir path for chart")
 	templateCmd.Flags().StringVarP(&valuesFilePath, "values", "f", "", "Overwrite chart values file")
-	templateCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Dir path for writing templated manifests")
+	templateCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Dir path for writing templated manifests, printed to stdout if not set")
 	templateCmd.Flags().BoolVarP(&fullRender, "full-render", "r", false, "Full render templates, including UUID's, RefID's and other data required in spinnaker.")
 	templateCmd.Flags().BoolVarP(&excludeDefaultValues, "exclude-default-values", "", false, "Don't use the default Values.yaml file from the chart.")
 	templateCmd.MarkFlagRequired("chart")
-	templateCmd.MarkFlagRequired("output")
 	rootCmd.AddCommand(templateCmd)
 }
 
-func Template() {
-	t := chart.Template{}
-	t.TemplateChart(chartPath, valuesFilePath, outputPath, fullRender, excludeDefaultValues)
+func Template() error {
+	t, err := newTemplate()
+	if err != nil {
+		return err
+	}
+	if outputPath != "" {
+		return t.TemplateChart(chartPath, valuesFilePath, outputPath, fullRender, excludeDefaultValues)
+	}
+	rendered, err := renderChart(t, chartPath, valuesFilePath, fullRender, excludeDefaultValues)
+	if err != nil {
+		return err
+	}
+	_, err = rendered.WriteTo(os.Stdout)
+	return err
+}
+
+// newTemplate returns a chart template processing the stages with the options of the config file
+func newTemplate() (*chart.Template, error) {
+	options, err := stageOptions()
+	if err != nil {
+		return nil, err
+	}
+	return &chart.Template{StageOptions: options}, nil
+}
+
+// renderChart renders the chart in memory as a multi document yaml stream
+func renderChart(t *chart.Template, chartPath, valuesFile string, fullRender, excludeDefaultValues bool) (*bytes.Buffer, error) {
+	renderedTemplates, err := t.RenderChart(chartPath, valuesFile, fullRender, excludeDefaultValues)
+	if err != nil {
+		return nil, err
+	}
+	rendered := new(bytes.Buffer)
+	if err = chart.WriteManifests(rendered, renderedTemplates); err != nil {
+		return nil, err
+	}
+	return rendered, nil
 }
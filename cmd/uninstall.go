@@ -13,37 +13,74 @@ governing permissions and limitations under the License.
 package cmd
 
 import (
-	"os"
-	"swinch/domain/datastore"
-
+	"context"
 	"github.com/spf13/cobra"
+	"swinch/domain/manifest"
+	"swinch/domain/release"
 )
 
 // uninstallCmd represents the uninstall command
 var uninstallCmd = &cobra.Command{
-	Use:   "uninstall",
-	Short: "Uninstalls a swinch chart",
-	Long:  `Uninstalls a swinch chart.`,
+	Use:   "uninstall [release]",
+	Short: "Uninstalls a swinch chart release",
+	Long: `Uninstalls a swinch chart release, the chart is rendered in memory and its manifests are deleted.
+The uninstall is recorded as a revision of an installed release, the release name defaults to the chart name.`,
+	Args: cobra.MaximumNArgs(1),
 	PreRun: func(cmd *cobra.Command, args []string) {
 		SetLogLevel(logLevel)
 		ValidateConfigFile()
 		ValidateConfig()
 	},
-	Run: func(cmd *cobra.Command, args []string) {
-		// Template call
-		d := datastore.Datastore{}
-		outputPath = d.CreateTmpFolder()
-		defer os.RemoveAll(outputPath)
-		templateCmd.Run(cmd, []string{})
-
-		// Delete call
-		filePath = outputPath
-		deleteCmd.Run(cmd, []string{})
+	RunE: func(cmd *cobra.Command, args []string) error {
+		releaseName := ""
+		if len(args) > 0 {
+			releaseName = args[0]
+		}
+		return runUninstall(cmd.Context(), releaseName)
 	},
 }
 
 func init() {
 	uninstallCmd.Flags().StringVarP(&chartPath, "chart", "c", "", "Dir path for chart")
+	uninstallCmd.Flags().StringVarP(&stateDir, "state-dir", "", "", "Release state directory, defaults to ~/.swinch/releases")
 	uninstallCmd.MarkFlagRequired("chart")
 	rootCmd.AddCommand(uninstallCmd)
 }
+
+func runUninstall(ctx context.Context, releaseName string) error {
+	t, err := newTemplate()
+	if err != nil {
+		return err
+	}
+	rendered, err := renderChart(t, chartPath, valuesFilePath, false, false)
+	if err != nil {
+		return err
+	}
+	m := manifest.Manifest{}
+	manifests, err := m.Decode(rendered)
+	if err != nil {
+		return err
+	}
+
+	metadata, releaseName, err := chartRelease(chartPath, releaseName)
+	if err != nil {
+		return err
+	}
+	history, err := releaseStore().History(releaseName)
+	if err != nil {
+		return err
+	}
+
+	deleteErr := deleteManifests(ctx, manifests)
+	// a chart never installed as a release has no history to record the uninstall in
+	if len(history) == 0 {
+		return deleteErr
+	}
+	return recordRevision(release.Revision{
+		Release:      releaseName,
+		Status:       release.Uninstalled,
+		Description:  "Uninstall",
+		Chart:        metadata.Name,
+		ChartVersion: metadata.Version,
+	}, deleteErr)
+}
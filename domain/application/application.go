@@ -13,7 +13,10 @@ governing permissions and limitations under the License.
 package application
 
 import (
+	"context"
+	"encoding/json"
 	log "github.com/sirupsen/logrus"
+	"swinch/domain/change"
 	"swinch/domain/datastore"
 	"swinch/domain/util"
 	"swinch/spincli"
@@ -21,39 +24,86 @@ import (
 
 type Application struct {
 	Manifest
-	spincli.ApplicationAPI
 	util.Util
 	datastore.Datastore
+	// Backend stores the applications, Spinnaker Gate is used if none is set
+	Backend spincli.ApplicationBackend
 }
 
-func (a *Application) Plan() {
-	a.Apply(true, true)
+func (a *Application) backend() spincli.ApplicationBackend {
+	if a.Backend == nil {
+		a.Backend = &spincli.ApplicationAPI{}
+	}
+	return a.Backend
 }
 
-func (a *Application) Apply(dryRun, plan bool) {
-	existingApp := a.Get(a.Metadata.Name)
-	changes := false
-	newApp := false
-	if len(existingApp) == 0 {
-		newApp = true
-	} else {
-		changes = a.Changes(a.MarshalJSON(a.loadSpec(existingApp)), a.MarshalJSON(a.Spec))
-		if changes == false {
-			log.Infof("No changes detected for application '%v'", a.Metadata.Name)
-		}
+func (a *Application) Plan(ctx context.Context) error {
+	return a.Apply(ctx, true, true)
+}
+
+func (a *Application) Apply(ctx context.Context, dryRun, plan bool) error {
+	c, err := a.Change(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.Action == change.NoOp {
+		log.Infof("No changes detected for application '%v'", a.Metadata.Name)
 	}
 
-	if changes && plan {
+	if c.Action == change.Update && plan {
 		log.Infof("Planing changes for application '%v'", a.Metadata.Name)
-		a.DiffChanges(a.MarshalJSON(a.loadSpec(existingApp)), a.MarshalJSON(a.Spec))
+		log.Info(c.FieldsDiff())
+	}
+
+	if !dryRun && c.Action != change.NoOp {
+		return a.Save(ctx)
+	}
+	return nil
+}
+
+// Change compares the application in Spinnaker with the manifest
+func (a *Application) Change(ctx context.Context) (change.Change, error) {
+	existingApp, err := a.backend().Get(ctx, a.Metadata.Name)
+	if err != nil {
+		return change.Change{}, err
+	}
+	var existingJSON []byte
+	if len(existingApp) != 0 {
+		existingSpec, err := a.loadSpec(existingApp)
+		if err != nil {
+			return change.Change{}, err
+		}
+		if existingJSON, err = a.MarshalJSON(existingSpec); err != nil {
+			return change.Change{}, err
+		}
+	}
+	newJSON, err := a.MarshalJSON(a.Spec)
+	if err != nil {
+		return change.Change{}, err
 	}
+	return change.New(Kind, a.Spec.Name, a.Spec.Name, existingJSON, newJSON)
+}
+
+// Save creates or updates the application in Spinnaker
+func (a *Application) Save(ctx context.Context) error {
+	log.Infof("Saving application '%v'", a.Metadata.Name)
+	return a.backend().Save(ctx, a.Metadata.Name, a.Spec)
+}
+
+// Snapshot returns the application saved in Spinnaker as is, empty if the application is missing, to be restored with Restore
+func (a *Application) Snapshot(ctx context.Context) ([]byte, error) {
+	return a.backend().Get(ctx, a.Metadata.Name)
+}
 
-	if !dryRun && (changes || newApp) {
-		log.Infof("Saving application '%v'", a.Metadata.Name)
-		a.Save(a.Metadata.Name, a.WriteJSONTmp(a.Spec))
+// Restore saves back an application snapshot, an empty snapshot deletes the application
+func (a *Application) Restore(ctx context.Context, snapshot []byte) error {
+	if len(snapshot) == 0 {
+		return a.backend().Delete(ctx, a.Metadata.Name)
 	}
+	return a.backend().Save(ctx, a.Metadata.Name, json.RawMessage(snapshot))
 }
 
-func (a *Application) Destroy() {
-	a.Delete(a.Metadata.Name)
+func (a *Application) Destroy(ctx context.Context) error {
+	return a.backend().Delete(ctx, a.Metadata.Name)
 }
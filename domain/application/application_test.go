@@ -0,0 +1,81 @@
+package application
+
+import (
+	"context"
+	"github.com/go-test/deep"
+	"gopkg.in/yaml.v3"
+	"swinch/spincli"
+	"swinch/spincli/gatetest"
+	_ "swinch/testing"
+	"testing"
+)
+
+func TestApplyApplication(t *testing.T) {
+	memory := spincli.NewMemory()
+	gate := gatetest.NewServer()
+	defer gate.Close()
+
+	backends := map[string]spincli.ApplicationBackend{
+		"memory": memory.Applications(),
+		"gate":   &spincli.ApplicationAPI{Gate: spincli.Gate{Client: gate.Client()}},
+	}
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			manifest := Manifest{}
+			if err := yaml.Unmarshal(readFile(t, "test/manifests/test_import/application.yaml"), &manifest); err != nil {
+				t.Fatal(err)
+			}
+
+			a := Application{Backend: backend}
+			if _, err := a.Load(manifest); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := a.Plan(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if existingApp := getApplication(t, backend, a.Metadata.Name); len(existingApp) != 0 {
+				t.Fatalf("plan saved the application: %s", existingApp)
+			}
+
+			if err := a.Apply(context.Background(), false, false); err != nil {
+				t.Fatal(err)
+			}
+			if diff := deep.Equal(loadSpec(t, getApplication(t, backend, a.Metadata.Name)), a.Spec); diff != nil {
+				t.Error(diff)
+			}
+
+			a.Spec.Email = "updated@example.com"
+			if err := a.Apply(context.Background(), false, true); err != nil {
+				t.Fatal(err)
+			}
+			if diff := deep.Equal(loadSpec(t, getApplication(t, backend, a.Metadata.Name)), a.Spec); diff != nil {
+				t.Error(diff)
+			}
+
+			if err := a.Destroy(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if existingApp := getApplication(t, backend, a.Metadata.Name); len(existingApp) != 0 {
+				t.Errorf("application not deleted: %s", existingApp)
+			}
+		})
+	}
+}
+
+func getApplication(t *testing.T, backend spincli.ApplicationBackend, appName string) []byte {
+	app, err := backend.Get(context.Background(), appName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func loadSpec(t *testing.T, app []byte) Spec {
+	a := Application{}
+	spec, err := a.loadSpec(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package application
+
+import (
+	"context"
+	"fmt"
+)
+
+// Import gets an application from Spinnaker and converts it to a swinch manifest
+func (a *Application) Import(ctx context.Context, appName string) (Manifest, error) {
+	existingApp, err := a.backend().Get(ctx, appName)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if len(existingApp) == 0 {
+		return Manifest{}, fmt.Errorf("application '%v' not found", appName)
+	}
+	return a.ImportJSON(existingApp)
+}
+
+// ImportJSON converts a Spinnaker application JSON to a swinch manifest
+func (a *Application) ImportJSON(appJSON []byte) (Manifest, error) {
+	spec, err := a.loadSpec(appJSON)
+	if err != nil {
+		return Manifest{}, err
+	}
+	a.Manifest = Manifest{
+		ApiVersion: API,
+		Kind:       Kind,
+		Metadata: Metadata{
+			Name: spec.Name,
+		},
+		Spec: spec,
+	}
+
+	return a.Manifest, nil
+}
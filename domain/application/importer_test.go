@@ -0,0 +1,35 @@
+package application
+
+import (
+	"github.com/go-test/deep"
+	"swinch/domain/datastore"
+	_ "swinch/testing"
+	"testing"
+)
+
+func TestImportJSON(t *testing.T) {
+	d := datastore.Datastore{}
+	a := Application{}
+
+	control := readFile(t, "test/manifests/test_import/application.yaml")
+	manifest, err := a.ImportJSON(readFile(t, "test/import/application.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := d.MarshalYAML(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(string(control), string(imported)); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func readFile(t *testing.T, filePath string) []byte {
+	d := datastore.Datastore{}
+	byteData, err := d.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return byteData
+}
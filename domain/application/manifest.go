@@ -15,7 +15,7 @@ package application
 import (
 	"encoding/json"
 	"errors"
-	log "github.com/sirupsen/logrus"
+	"fmt"
 	"gopkg.in/yaml.v3"
 	"strings"
 	"swinch/domain/datastore"
@@ -58,23 +58,31 @@ func (a *Application) GetKind() string {
 	return Kind
 }
 
-func (a *Application) Load(manifest interface{}) *Application {
-	a.decode(manifest)
+func (a *Application) Load(manifest interface{}) (*Application, error) {
+	err := a.decode(manifest)
+	if err != nil {
+		return nil, err
+	}
 	a.inferFromManifest()
 
-	err := a.validate()
+	err = a.validate()
 	if err != nil {
-		log.Fatalf("Application manifest validation failed: %v", err)
+		return nil, fmt.Errorf("application manifest validation failed: %w", err)
 	}
-	return a
+	return a, nil
 }
 
-func (a *Application) decode(manifest interface{}) {
+func (a *Application) decode(manifest interface{}) error {
 	d := datastore.Datastore{}
-	err := yaml.Unmarshal(d.MarshalYAML(manifest), &a.Manifest)
+	manifestYAML, err := d.MarshalYAML(manifest)
 	if err != nil {
-		log.Fatalf("Error Load: %v", err)
+		return err
 	}
+	err = yaml.Unmarshal(manifestYAML, &a.Manifest)
+	if err != nil {
+		return fmt.Errorf("error loading application manifest: %w", err)
+	}
+	return nil
 }
 
 func (a *Application) inferFromManifest() {
@@ -89,12 +97,12 @@ func (a *Application) validate() error {
 	return nil
 }
 
-func (a *Application) loadSpec(spec []byte) Spec {
+func (a *Application) loadSpec(spec []byte) (Spec, error) {
 	tmpSpec := new(Spec)
 	err := json.Unmarshal(spec, tmpSpec)
 
 	if err != nil {
-		log.Fatalf("Error loading spec: %v", err)
+		return Spec{}, fmt.Errorf("error loading spec: %w", err)
 	}
-	return *tmpSpec
+	return *tmpSpec, nil
 }
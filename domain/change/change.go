@@ -0,0 +1,76 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package change
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Action string
+
+const (
+	Create Action = "create"
+	Update Action = "update"
+	NoOp   Action = "no-op"
+	Delete Action = "delete"
+)
+
+// Change is the planned change of a Spinnaker application or pipeline
+// Existing and Desired hold the Spinnaker JSON specs, Existing is empty for new objects and Desired is empty for deleted objects
+type Change struct {
+	Kind        string      `yaml:"kind" json:"kind"`
+	Application string      `yaml:"application" json:"application"`
+	Name        string      `yaml:"name" json:"name"`
+	Action      Action      `yaml:"action" json:"action"`
+	Fields      []FieldDiff `yaml:"fields,omitempty" json:"fields,omitempty"`
+	Existing    []byte      `yaml:"-" json:"-"`
+	Desired     []byte      `yaml:"-" json:"-"`
+}
+
+// New returns the change from the existing spec to the desired spec, the field diffs are listed for updates
+func New(kind, application, name string, existing, desired []byte) (Change, error) {
+	c := Change{Kind: kind, Application: application, Name: name, Existing: existing, Desired: desired}
+	switch {
+	case len(existing) == 0:
+		c.Action = Create
+	case len(desired) == 0:
+		c.Action = Delete
+	default:
+		fields, err := Fields(existing, desired)
+		if err != nil {
+			return Change{}, fmt.Errorf("failed to diff %v '%v': %w", kind, name, err)
+		}
+		if len(fields) == 0 {
+			c.Action = NoOp
+			break
+		}
+		c.Action = Update
+		c.Fields = fields
+	}
+	return c, nil
+}
+
+// Resource returns the change kind and name, used to report the change
+func (c Change) Resource() string {
+	return fmt.Sprintf("%v/%v", c.Kind, c.Name)
+}
+
+// FieldsDiff returns the changed fields, one per line
+func (c Change) FieldsDiff() string {
+	fields := make([]string, 0)
+	for _, field := range c.Fields {
+		fields = append(fields, field.String())
+	}
+	return strings.Join(fields, "\n")
+}
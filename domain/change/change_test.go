@@ -0,0 +1,119 @@
+package change
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/go-test/deep"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	existing := []byte(`{"name": "deploy", "stages": [{"account": "a", "refId": "1"}], "disabled": false}`)
+	desired := []byte(`{"name": "deploy", "stages": [{"account": "b", "refId": "1"}, {"account": "c", "refId": "2"}], "spelEvaluator": "v4"}`)
+
+	tests := map[string]struct {
+		existing, desired []byte
+		action            Action
+		fields            []FieldDiff
+	}{
+		"create": {nil, desired, Create, nil},
+		"delete": {existing, nil, Delete, nil},
+		"no-op":  {existing, existing, NoOp, nil},
+		"update": {existing, desired, Update, []FieldDiff{
+			{Path: "spelEvaluator", Old: nil, New: "v4"},
+			{Path: "stages[1].account", Old: "a", New: "b"},
+			{Path: "stages[2]", Old: nil, New: map[string]interface{}{"account": "c", "refId": "2"}},
+		}},
+		"ignored fields": {existing, []byte(`{"name": "deploy", "stages": [{"account": "a", "refId": "1"}], "index": 3, "id": "uuid", "updateTs": "1"}`), NoOp, nil},
+		"zero fields missing in Spinnaker": {
+			existing,
+			[]byte(`{"name": "deploy", "stages": [{"account": "a", "refId": "1", "overrides": {}, "skipExpressionEvaluation": false}], "disabled": false, "triggers": []}`),
+			NoOp, nil,
+		},
+		"owner version": {
+			[]byte(`{"name": "deploy", "managedBy": {"chart": "chart", "version": "0.1.1"}}`),
+			[]byte(`{"name": "deploy", "managedBy": {"chart": "chart", "version": "0.2.0"}}`),
+			NoOp, nil,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, err := New("Pipeline", "test", "deploy", test.existing, test.desired)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Action != test.action {
+				t.Errorf("expected action %v, got %v", test.action, c.Action)
+			}
+			if diff := deep.Equal(c.Fields, test.fields); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestFieldsByName(t *testing.T) {
+	existing := []byte(`{"stages": [{"name": "bake", "refId": "1"}, {"name": "deploy-prod", "refId": "2", "account": "a"}]}`)
+	desired := []byte(`{"stages": [{"name": "deploy-prod", "refId": "1", "account": "b"}, {"name": "bake", "refId": "2"}]}`)
+
+	fields, err := Fields(existing, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"stages[bake].refId: 1 -> 2",
+		"stages[deploy-prod].account: a -> b",
+		"stages[deploy-prod].refId: 2 -> 1",
+	}
+	result := make([]string, 0)
+	for _, field := range fields {
+		result = append(result, field.String())
+	}
+	if diff := deep.Equal(result, expected); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestFieldDiffString(t *testing.T) {
+	f := FieldDiff{Path: "stages[1]", Old: nil, New: map[string]interface{}{"account": "c"}}
+	if diff := deep.Equal(f.String(), `stages[1]: <none> -> {"account":"c"}`); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPlanWriteJSON(t *testing.T) {
+	p := Plan{}
+	p.Add(Change{Kind: "Pipeline", Application: "test", Name: "deploy", Action: Update, Fields: []FieldDiff{{Path: "name", Old: "a", New: "b"}}})
+	p.Add(Change{Kind: "Application", Application: "test", Name: "test", Action: NoOp})
+
+	buffer := new(bytes.Buffer)
+	if err := p.Write(buffer, JSONFormat); err != nil {
+		t.Fatal(err)
+	}
+	output := make(map[string]interface{})
+	if err := json.Unmarshal(buffer.Bytes(), &output); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"summary": map[string]interface{}{"create": 0.0, "update": 1.0, "noOp": 1.0, "delete": 0.0},
+		"changes": []interface{}{
+			map[string]interface{}{"kind": "Pipeline", "application": "test", "name": "deploy", "action": "update",
+				"fields": []interface{}{map[string]interface{}{"path": "name", "old": "a", "new": "b"}}},
+			map[string]interface{}{"kind": "Application", "application": "test", "name": "test", "action": "no-op"},
+		},
+	}
+	if diff := deep.Equal(output, expected); diff != nil {
+		t.Error(diff)
+	}
+
+	if err := p.Write(buffer, "xml"); err == nil {
+		t.Error("expected an unknown format error")
+	}
+	buffer.Reset()
+	if err := p.Write(buffer, TableFormat); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buffer.Bytes(), []byte("name: a -> b")) {
+		t.Errorf("expected the field diff in the table, got:\n%v", buffer.String())
+	}
+}
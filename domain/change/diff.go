@@ -0,0 +1,234 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package change
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ignoredFields are set by Spinnaker on the saved specs and are not managed by the manifests
+var ignoredFields = map[string]bool{"index": true, "id": true, "updateTs": true, "lastModifiedBy": true}
+
+// ignoredPaths are nested fields not managed by the manifests, the swinch version of the owner changes with every swinch upgrade
+var ignoredPaths = map[string]bool{"managedBy.version": true}
+
+// listKeys identify the elements of a list of objects, like stages by name or refId
+var listKeys = []string{"name", "refId"}
+
+// FieldDiff is a field changed between the existing and the desired spec
+// Old is nil for added fields and New is nil for removed fields
+type FieldDiff struct {
+	Path string      `yaml:"path" json:"path"`
+	Old  interface{} `yaml:"old" json:"old"`
+	New  interface{} `yaml:"new" json:"new"`
+}
+
+func (f FieldDiff) String() string {
+	return fmt.Sprintf("%v: %v -> %v", f.Path, formatValue(f.Old), formatValue(f.New))
+}
+
+// Fields compares two JSON specs and returns the changed fields
+// Lists of objects are matched by name or refId, so reordered stages are not a change
+// Fields Spinnaker sets on save and fields missing from one spec while holding a zero value in the other are ignored
+func Fields(existing, desired []byte) ([]FieldDiff, error) {
+	var existingSpec, desiredSpec interface{}
+	if err := json.Unmarshal(existing, &existingSpec); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(desired, &desiredSpec); err != nil {
+		return nil, err
+	}
+
+	if existingMap, ok := existingSpec.(map[string]interface{}); ok {
+		existingSpec = withoutIgnored(existingMap)
+	}
+	if desiredMap, ok := desiredSpec.(map[string]interface{}); ok {
+		desiredSpec = withoutIgnored(desiredMap)
+	}
+
+	fields := make([]FieldDiff, 0)
+	compare("", existingSpec, desiredSpec, &fields)
+	return fields, nil
+}
+
+func compare(path string, existing, desired interface{}, fields *[]FieldDiff) {
+	switch existingValue := existing.(type) {
+	case map[string]interface{}:
+		desiredValue, ok := desired.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(existingValue, desiredValue) {
+			existingField, existingOk := existingValue[key]
+			desiredField, desiredOk := desiredValue[key]
+			// a field missing on one side and zero on the other is not a change, Spinnaker leaves some zero fields out
+			if (!desiredOk && isZero(existingField)) || (!existingOk && isZero(desiredField)) || ignoredPaths[joinPath(path, key)] {
+				continue
+			}
+			compare(joinPath(path, key), existingField, desiredField, fields)
+		}
+		return
+	case []interface{}:
+		desiredValue, ok := desired.([]interface{})
+		if !ok {
+			break
+		}
+		if key, ok := listKey(existingValue, desiredValue); ok {
+			compareByKey(path, key, existingValue, desiredValue, fields)
+			return
+		}
+		for i := 0; i < len(existingValue) || i < len(desiredValue); i++ {
+			var e, d interface{}
+			if i < len(existingValue) {
+				e = existingValue[i]
+			}
+			if i < len(desiredValue) {
+				d = desiredValue[i]
+			}
+			compare(fmt.Sprintf("%v[%d]", path, i), e, d, fields)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(existing, desired) {
+		*fields = append(*fields, FieldDiff{Path: path, Old: existing, New: desired})
+	}
+}
+
+// compareByKey compares the list elements with the same key, existing elements first in their order, then the added elements
+func compareByKey(path, key string, existing, desired []interface{}, fields *[]FieldDiff) {
+	desiredByKey := make(map[string]interface{})
+	for _, element := range desired {
+		desiredByKey[keyValue(element, key)] = element
+	}
+
+	existingKeys := make(map[string]bool)
+	for _, element := range existing {
+		k := keyValue(element, key)
+		existingKeys[k] = true
+		compare(fmt.Sprintf("%v[%v]", path, k), element, desiredByKey[k], fields)
+	}
+	for _, element := range desired {
+		k := keyValue(element, key)
+		if !existingKeys[k] {
+			compare(fmt.Sprintf("%v[%v]", path, k), nil, element, fields)
+		}
+	}
+}
+
+// listKey returns the first key set and unique on every object of both lists
+func listKey(existing, desired []interface{}) (string, bool) {
+	for _, key := range listKeys {
+		if uniqueKey(existing, key) && uniqueKey(desired, key) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func uniqueKey(list []interface{}, key string) bool {
+	seen := make(map[string]bool)
+	for _, element := range list {
+		k := keyValue(element, key)
+		if k == "" || seen[k] {
+			return false
+		}
+		seen[k] = true
+	}
+	return true
+}
+
+func keyValue(element interface{}, key string) string {
+	object, ok := element.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	switch value := object[key].(type) {
+	case string:
+		return value
+	case float64:
+		return fmt.Sprintf("%v", value)
+	default:
+		return ""
+	}
+}
+
+func withoutIgnored(spec map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for key, value := range spec {
+		if !ignoredFields[key] {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+func isZero(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+func sortedKeys(maps ...map[string]interface{}) []string {
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// formatValue prints a JSON value on a single line, missing values are printed as <none>
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "<none>"
+	case string:
+		return v
+	default:
+		byteData, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(byteData)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package change
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+)
+
+const (
+	JSONFormat  = "json"
+	YAMLFormat  = "yaml"
+	TableFormat = "table"
+)
+
+var Formats = []string{JSONFormat, YAMLFormat, TableFormat}
+
+// Plan lists the changes of every planned application and pipeline
+type Plan struct {
+	Changes []Change `yaml:"changes" json:"changes"`
+}
+
+// Summary counts the planned changes by action
+type Summary struct {
+	Create int `yaml:"create" json:"create"`
+	Update int `yaml:"update" json:"update"`
+	NoOp   int `yaml:"noOp" json:"noOp"`
+	Delete int `yaml:"delete" json:"delete"`
+}
+
+func (p *Plan) Add(c Change) {
+	p.Changes = append(p.Changes, c)
+}
+
+func (p Plan) Summary() Summary {
+	s := Summary{}
+	for _, c := range p.Changes {
+		switch c.Action {
+		case Create:
+			s.Create++
+		case Update:
+			s.Update++
+		case NoOp:
+			s.NoOp++
+		case Delete:
+			s.Delete++
+		}
+	}
+	return s
+}
+
+// HasChanges returns true if any planned change is not a no-op
+func (p Plan) HasChanges() bool {
+	for _, c := range p.Changes {
+		if c.Action != NoOp {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d to create, %d to update, %d to delete, %d unchanged", s.Create, s.Update, s.Delete, s.NoOp)
+}
+
+// Write prints the plan in one of the output formats
+func (p Plan) Write(w io.Writer, format string) error {
+	output := struct {
+		Summary Summary  `yaml:"summary" json:"summary"`
+		Changes []Change `yaml:"changes" json:"changes"`
+	}{p.Summary(), p.Changes}
+	if output.Changes == nil {
+		output.Changes = make([]Change, 0)
+	}
+
+	switch format {
+	case JSONFormat:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", " ")
+		return encoder.Encode(output)
+	case YAMLFormat:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(output); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case TableFormat:
+		p.writeTable(w)
+		return nil
+	default:
+		return fmt.Errorf("unknown output format '%v', supported formats: %v", format, strings.Join(Formats, ", "))
+	}
+}
+
+func (p Plan) writeTable(w io.Writer) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{"KIND", "APPLICATION", "NAME", "ACTION", "CHANGES"})
+	t.AppendSeparator()
+
+	for _, c := range p.Changes {
+		t.AppendRow(table.Row{c.Kind, c.Application, c.Name, c.Action, c.FieldsDiff()})
+	}
+	t.AppendFooter(table.Row{"", "", "", "", p.Summary().String()})
+
+	t.SetStyle(table.Style{
+		Name: "swinch",
+		Box: table.BoxStyle{
+			PaddingLeft:  "",
+			PaddingRight: "     ",
+		},
+		Format: table.FormatOptions{
+			Footer: text.FormatDefault,
+			Header: text.FormatDefault,
+		},
+	})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{
+			Name:        "ACTION",
+			Align:       text.AlignCenter,
+			AlignHeader: text.AlignCenter,
+		},
+	})
+	t.Render()
+}
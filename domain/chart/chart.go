@@ -13,8 +13,9 @@ governing permissions and limitations under the License.
 package chart
 
 import (
-	log "github.com/sirupsen/logrus"
+	"fmt"
 	"path"
+	"strings"
 	"swinch/domain/datastore"
 )
 
@@ -36,28 +37,62 @@ type Chart struct {
 
 // Import
 
-func (c Chart) GenerateChart(manifest interface{}) {
+func (c Chart) GenerateChart(manifest interface{}) error {
+	return c.GenerateChartTemplates(map[string]interface{}{c.Kind: manifest})
+}
+
+// GenerateChartTemplates generates a chart with a template file for each named manifest
+func (c Chart) GenerateChartTemplates(manifests map[string]interface{}) error {
 	if c.FileExists(path.Join(c.OutputPath, c.Metadata.Name, ValuesFile)) && c.ProtectedImport {
-		log.Fatalf("Cannot import over an existing chart, values file present in path '%s'", path.Join(c.OutputPath, c.Metadata.Name, ValuesFile))
-	} else {
-		c.Mkdir(path.Join(c.OutputPath, c.Metadata.Name, "/", TemplatesFolder), FilePerm)
-		c.WriteChartMetadata()
-		c.WriteChartValues()
-		c.WriteManifest(manifest)
+		return fmt.Errorf("cannot import over an existing chart, values file present in path '%s'", path.Join(c.OutputPath, c.Metadata.Name, ValuesFile))
+	}
+
+	err := c.Mkdir(path.Join(c.OutputPath, c.Metadata.Name, "/", TemplatesFolder), FilePerm)
+	if err != nil {
+		return err
+	}
+	err = c.WriteChartMetadata()
+	if err != nil {
+		return err
+	}
+	err = c.WriteChartValues()
+	if err != nil {
+		return err
 	}
+	for name, manifest := range manifests {
+		err = c.WriteTemplate(name, manifest)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // WriteChartMetadata default Chart metadata for imported pipelines
-func (c Chart) WriteChartMetadata() {
-	c.Metadata = c.loadMetadata([]byte(DefaultChartMetadata))
-	c.WriteYAML(c.Metadata, path.Join(c.OutputPath, c.Metadata.Name, MetadataFile))
+func (c Chart) WriteChartMetadata() error {
+	metadata, err := c.loadMetadata([]byte(DefaultChartMetadata))
+	if err != nil {
+		return err
+	}
+	c.Metadata = metadata
+	return c.WriteYAML(c.Metadata, path.Join(c.OutputPath, c.Metadata.Name, MetadataFile))
 }
 
 // WriteChartValues default Chart values for imported pipelines
-func (c Chart) WriteChartValues() {
-	c.WriteYAML(c.Values.Values, path.Join(c.OutputPath, c.Metadata.Name, ValuesFile))
+func (c Chart) WriteChartValues() error {
+	return c.WriteYAML(c.Values.Values, path.Join(c.OutputPath, c.Metadata.Name, ValuesFile))
+}
+
+func (c Chart) WriteManifest(manifest interface{}) error {
+	return c.WriteTemplate(c.Kind, manifest)
+}
+
+func (c Chart) WriteTemplate(name string, manifest interface{}) error {
+	return c.WriteYAML(manifest, path.Join(c.OutputPath, c.Metadata.Name, "/", TemplatesFolder, name+".yaml"))
 }
 
-func (c Chart) WriteManifest(manifest interface{}) {
-	c.WriteYAML(manifest, path.Join(c.OutputPath, c.Metadata.Name, "/", TemplatesFolder, c.Kind+".yaml"))
+// FileName converts an object name, like a pipeline name, to a chart or template file name
+func FileName(name string) string {
+	name = strings.NewReplacer("/", " ", "\\", " ").Replace(name)
+	return strings.ToLower(strings.Join(strings.Fields(name), "-"))
 }
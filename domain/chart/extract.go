@@ -0,0 +1,198 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package chart
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strings"
+	"swinch/domain/datastore"
+)
+
+// ValueRule pulls the manifest fields holding repeated literals in chart values
+// Paths are dot separated manifest keys, lists are traversed transparently
+type ValueRule struct {
+	Value string   `yaml:"value" json:"value"`
+	Paths []string `yaml:"paths" json:"paths"`
+}
+
+var DefaultValueRules = []ValueRule{
+	{Value: "account", Paths: []string{"spec.stages.account"}},
+	{Value: "namespace", Paths: []string{"spec.stages.namespace", "spec.stages.namespaceOverride"}},
+	{Value: "artifactAccount", Paths: []string{"spec.stages.inputArtifacts.account"}},
+	{Value: "jenkinsMaster", Paths: []string{"spec.stages.master"}},
+	{Value: "ldapGroup", Paths: []string{
+		"spec.permissions.EXECUTE",
+		"spec.permissions.READ",
+		"spec.permissions.WRITE",
+		"spec.stages.selectedStageRoles",
+		"spec.stages.parameters.LdapEditGroup",
+		"spec.stages.parameters.LdapViewGroup",
+		"spec.stages.parameters.SpinnakerOnlyGroup",
+	}},
+}
+
+type Extractor struct {
+	Rules []ValueRule
+	datastore.Datastore
+}
+
+// occurrence is a manifest field holding a literal
+type occurrence struct {
+	literal string
+	set     func(value string)
+}
+
+// LoadValueRules loads the value rules from a yaml file, the default rules are used if no file is provided
+func LoadValueRules(rulesFile string) ([]ValueRule, error) {
+	if rulesFile == "" {
+		return DefaultValueRules, nil
+	}
+	d := datastore.Datastore{}
+	rulesYAML, err := d.ReadFile(rulesFile)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]ValueRule, 0)
+	err = yaml.Unmarshal(rulesYAML, &rules)
+	if err != nil {
+		return nil, fmt.Errorf("error loading value rules: %w", err)
+	}
+	return rules, nil
+}
+
+// ExtractValues replaces the literals repeated in the manifests with chart values
+// a rule matching a single repeated literal gets the rule value name, multiple repeated literals get numbered value names
+// returns the chart values and the templated manifests
+func (e Extractor) ExtractValues(manifests map[string]interface{}) (Values, map[string]interface{}, error) {
+	values := Values{Values: make(map[interface{}]interface{})}
+	templates := make(map[string]interface{})
+	names := make([]string, 0)
+	for name, manifest := range manifests {
+		template, err := e.toTemplate(manifest)
+		if err != nil {
+			return Values{}, nil, err
+		}
+		templates[name] = template
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, rule := range e.Rules {
+		occurrences := make([]occurrence, 0)
+		for _, name := range names {
+			for _, path := range rule.Paths {
+				e.find(templates[name], strings.Split(path, "."), &occurrences)
+			}
+		}
+
+		literals := repeatedLiterals(occurrences)
+		for i, literal := range literals {
+			value := rule.Value
+			if len(literals) > 1 {
+				value = fmt.Sprintf("%v%d", rule.Value, i+1)
+			}
+			log.Debugf("Extracting value '%v: %v'", value, literal)
+			values.Values[value] = literal
+			for _, o := range occurrences {
+				if o.literal == literal {
+					o.set(fmt.Sprintf("{{ .Values.%v }}", value))
+				}
+			}
+		}
+	}
+
+	return values, templates, nil
+}
+
+// toTemplate converts a manifest to generic data that can be edited in place
+func (e Extractor) toTemplate(manifest interface{}) (interface{}, error) {
+	manifestYAML, err := e.MarshalYAML(manifest)
+	if err != nil {
+		return nil, err
+	}
+	var template interface{}
+	err = yaml.Unmarshal(manifestYAML, &template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal: %w", err)
+	}
+	return e.escape(template), nil
+}
+
+func (e Extractor) find(data interface{}, keys []string, occurrences *[]occurrence) {
+	switch data := data.(type) {
+	case map[string]interface{}:
+		value, ok := data[keys[0]]
+		if !ok {
+			return
+		}
+		if len(keys) > 1 {
+			e.find(value, keys[1:], occurrences)
+			return
+		}
+
+		key := keys[0]
+		switch value := value.(type) {
+		case string:
+			*occurrences = append(*occurrences, occurrence{literal: value, set: func(v string) { data[key] = v }})
+		case []interface{}:
+			for i := range value {
+				if literal, ok := value[i].(string); ok {
+					index := i
+					*occurrences = append(*occurrences, occurrence{literal: literal, set: func(v string) { value[index] = v }})
+				}
+			}
+		}
+	case []interface{}:
+		for _, value := range data {
+			e.find(value, keys, occurrences)
+		}
+	}
+}
+
+// repeatedLiterals returns the literals found in more than one occurrence, in order of appearance
+func repeatedLiterals(occurrences []occurrence) []string {
+	count := make(map[string]int)
+	literals := make([]string, 0)
+	for _, o := range occurrences {
+		if o.literal == "" {
+			continue
+		}
+		count[o.literal]++
+		if count[o.literal] == 2 {
+			literals = append(literals, o.literal)
+		}
+	}
+	return literals
+}
+
+// escape protects the literals looking like go templates from being rendered
+func (e Extractor) escape(data interface{}) interface{} {
+	switch data := data.(type) {
+	case map[string]interface{}:
+		for key, value := range data {
+			data[key] = e.escape(value)
+		}
+	case []interface{}:
+		for i, value := range data {
+			data[i] = e.escape(value)
+		}
+	case string:
+		if strings.Contains(data, "{{") || strings.Contains(data, "}}") {
+			return "{{`" + data + "`}}"
+		}
+	}
+	return data
+}
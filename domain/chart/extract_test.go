@@ -0,0 +1,141 @@
+package chart
+
+import (
+	"github.com/go-test/deep"
+	"path"
+	"strings"
+	_ "swinch/testing"
+	"testing"
+)
+
+func TestExtractValues(t *testing.T) {
+	manifests := map[string]interface{}{
+		"first": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"stages": []interface{}{
+					map[string]interface{}{"account": "test-account", "namespace": "test-ns-1"},
+				},
+			},
+		},
+		"second": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"stages": []interface{}{
+					map[string]interface{}{"account": "test-account", "namespaceOverride": "test-ns-2"},
+				},
+			},
+		},
+	}
+
+	e := Extractor{Rules: DefaultValueRules}
+	values, templates, err := e.ExtractValues(manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	controlValues := Values{Values: map[interface{}]interface{}{"account": "test-account"}}
+	if diff := deep.Equal(values, controlValues); diff != nil {
+		t.Error(diff)
+	}
+
+	controlTemplate := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"stages": []interface{}{
+				map[string]interface{}{"account": "{{ .Values.account }}", "namespace": "test-ns-1"},
+			},
+		},
+	}
+	if diff := deep.Equal(templates["first"], controlTemplate); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestExtractNumberedValues(t *testing.T) {
+	manifests := map[string]interface{}{
+		"application": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"email": "{{ not a template }}",
+				"permissions": map[string]interface{}{
+					"READ":  []interface{}{"ldap-1", "ldap-2"},
+					"WRITE": []interface{}{"ldap-1", "ldap-2"},
+				},
+			},
+		},
+	}
+
+	e := Extractor{Rules: DefaultValueRules}
+	values, templates, err := e.ExtractValues(manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	controlValues := Values{Values: map[interface{}]interface{}{"ldapGroup1": "ldap-1", "ldapGroup2": "ldap-2"}}
+	if diff := deep.Equal(values, controlValues); diff != nil {
+		t.Error(diff)
+	}
+
+	controlTemplate := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"email": "{{`{{ not a template }}`}}",
+			"permissions": map[string]interface{}{
+				"READ":  []interface{}{"{{ .Values.ldapGroup1 }}", "{{ .Values.ldapGroup2 }}"},
+				"WRITE": []interface{}{"{{ .Values.ldapGroup1 }}", "{{ .Values.ldapGroup2 }}"},
+			},
+		},
+	}
+	if diff := deep.Equal(templates["application"], controlTemplate); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestExtractJenkinsParameters(t *testing.T) {
+	jenkinsPipeline := func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "spinnaker.adobe.com/alpha1",
+			"kind":       "Pipeline",
+			"metadata":   map[string]interface{}{"name": name, "application": "test"},
+			"spec": map[string]interface{}{
+				"stages": []interface{}{
+					map[string]interface{}{
+						"name":   "Build",
+						"type":   "jenkins",
+						"master": "ci",
+						"job":    "build",
+						"parameters": map[string]interface{}{
+							"LdapEditGroup": "editors",
+							"Replicas":      "3",
+						},
+					},
+				},
+			},
+		}
+	}
+	manifests := map[string]interface{}{"first": jenkinsPipeline("first"), "second": jenkinsPipeline("second")}
+
+	e := Extractor{Rules: DefaultValueRules}
+	values, templates, err := e.ExtractValues(manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Chart{OutputPath: t.TempDir(), Metadata: Metadata{Name: "jenkins"}, Values: values}
+	if err = c.GenerateChartTemplates(templates); err != nil {
+		t.Fatal(err)
+	}
+
+	// the extracted parameters are rendered back and the pipelines load
+	tp := Template{}
+	renderedTemplates, err := tp.RenderChart(path.Join(c.OutputPath, "jenkins"), "", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(renderedTemplates) != 2 {
+		t.Fatalf("expected 2 rendered templates, got %d", len(renderedTemplates))
+	}
+	for _, renderedTemplate := range renderedTemplates {
+		if !strings.Contains(renderedTemplate.Buffer.String(), "LdapEditGroup: editors") {
+			t.Errorf("expected the Jenkins parameter rendered in %v, got %v", renderedTemplate.Name, renderedTemplate.Buffer)
+		}
+	}
+	if diff := deep.Equal(values.Values["ldapGroup"], "editors"); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -13,7 +13,7 @@ governing permissions and limitations under the License.
 package chart
 
 import (
-	log "github.com/sirupsen/logrus"
+	"fmt"
 	"gopkg.in/yaml.v3"
 	"path"
 	"swinch/domain/datastore"
@@ -32,16 +32,24 @@ type Metadata struct {
 	Version     string `yaml:"version" json:"version"`
 }
 
-func (m Metadata) loadMetadataFile(ChartPath string) Metadata {
+func (m Metadata) loadMetadataFile(ChartPath string) (Metadata, error) {
 	d := datastore.Datastore{}
-	metadataBuffer := d.ReadFile(path.Join(ChartPath, "/Chart.yaml"))
+	metadataBuffer, err := d.ReadFile(path.Join(ChartPath, "/Chart.yaml"))
+	if err != nil {
+		return Metadata{}, err
+	}
 	return m.loadMetadata(metadataBuffer)
 }
 
-func (m Metadata) loadMetadata(byteData []byte) Metadata {
+func (m Metadata) loadMetadata(byteData []byte) (Metadata, error) {
 	err := yaml.Unmarshal(byteData, &m)
 	if err != nil {
-		log.Fatalf("Error loading Chart metadata: %v", err)
+		return Metadata{}, fmt.Errorf("error loading Chart metadata: %w", err)
 	}
-	return m
+	return m, nil
+}
+
+// LoadMetadata returns the chart metadata from the chart Chart.yaml
+func LoadMetadata(chartPath string) (Metadata, error) {
+	return Metadata{}.loadMetadataFile(chartPath)
 }
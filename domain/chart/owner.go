@@ -0,0 +1,116 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"io"
+	"path"
+	"swinch/domain/owner"
+	"swinch/domain/pipeline"
+)
+
+// chartOwner returns the owner of the manifests rendered from the chart, charts without metadata have no owner
+func (t *Template) chartOwner(chartPath string, values Values) (*owner.Owner, error) {
+	if !t.FileExists(path.Join(chartPath, MetadataFile)) {
+		log.Warnf("Chart '%v' has no %v, the rendered pipelines are not marked as managed by swinch", chartPath, MetadataFile)
+		return nil, nil
+	}
+	metadata, err := Metadata{}.loadMetadataFile(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	o, err := owner.New(metadata.Name, metadata.Version, values.Values)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// stampOwner sets the owner in the metadata of the rendered pipelines, the documents are decoded and encoded back with their comments
+// the owner set by a template is kept
+func (t *Template) stampOwner(buffer *bytes.Buffer, o owner.Owner) (*bytes.Buffer, error) {
+	stamped := new(bytes.Buffer)
+	encoder := yaml.NewEncoder(stamped)
+	encoder.SetIndent(2)
+	decoder := yaml.NewDecoder(bytes.NewReader(buffer.Bytes()))
+	for {
+		document := new(yaml.Node)
+		err := decoder.Decode(document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading YAML: %w", err)
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+
+		metadataKey, metadata := pipelineMetadata(document)
+		switch {
+		case metadata == nil || mappingValue(metadata, "owner") != nil:
+		case metadata.Kind != yaml.MappingNode:
+			log.Warnf("Pipeline metadata at line %v is not a mapping, the pipeline is not marked as managed by swinch", metadataKey.Line)
+		default:
+			ownerNode := new(yaml.Node)
+			if err = ownerNode.Encode(o); err != nil {
+				return nil, err
+			}
+			ownerKey := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "owner"}
+			metadata.Content = append([]*yaml.Node{ownerKey, ownerNode}, metadata.Content...)
+		}
+
+		if err = encoder.Encode(document); err != nil {
+			return nil, fmt.Errorf("error writing YAML: %w", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return stamped, nil
+}
+
+// pipelineMetadata returns the metadata key and value nodes of a pipeline document, nil for other documents
+func pipelineMetadata(document *yaml.Node) (*yaml.Node, *yaml.Node) {
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return nil, nil
+	}
+	root := document.Content[0]
+	kind := mappingValue(root, "kind")
+	if kind == nil || kind.Value != pipeline.Kind {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "metadata" {
+			return root.Content[i], root.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
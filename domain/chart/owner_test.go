@@ -0,0 +1,60 @@
+package chart
+
+import (
+	"bytes"
+	"github.com/go-test/deep"
+	"gopkg.in/yaml.v3"
+	"strings"
+	"swinch/domain/owner"
+	_ "swinch/testing"
+	"testing"
+)
+
+func TestStampOwner(t *testing.T) {
+	rendered := `
+apiVersion: spinnaker.adobe.com/alpha1
+kind: Application
+metadata:
+  name: test
+---
+# the deploy pipeline
+apiVersion: spinnaker.adobe.com/alpha1
+kind: Pipeline
+metadata: {name: deploy, application: test}
+---
+apiVersion: spinnaker.adobe.com/alpha1
+kind: Pipeline
+metadata:
+  name: notify
+  application: test
+  owner:
+    chart: other
+`
+	tp := Template{}
+	stamped, err := tp.stampOwner(bytes.NewBufferString(rendered), owner.Owner{Chart: "chart", ChartVersion: "0.1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stamped.String(), "# the deploy pipeline") {
+		t.Error("expected the comments to be kept")
+	}
+
+	owners := make([]interface{}, 0)
+	decoder := yaml.NewDecoder(stamped)
+	for {
+		document := make(map[string]interface{})
+		if err = decoder.Decode(&document); err != nil {
+			break
+		}
+		metadata, _ := document["metadata"].(map[string]interface{})
+		owners = append(owners, metadata["owner"])
+	}
+	expected := []interface{}{
+		nil,
+		map[string]interface{}{"chart": "chart", "chartVersion": "0.1.0", "valuesHash": "", "version": ""},
+		map[string]interface{}{"chart": "other"},
+	}
+	if diff := deep.Equal(owners, expected); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -14,76 +14,174 @@ package chart
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/Masterminds/sprig"
 	log "github.com/sirupsen/logrus"
+	"io"
 	"os"
 	"path"
 	"swinch/domain/datastore"
 	"swinch/domain/manifest"
+	"swinch/domain/pipeline"
+	"swinch/domain/stages"
 	"text/template"
 )
 
 type Template struct {
 	Values
 	datastore.Datastore
+	// StageOptions configure how the pipeline stages are processed by a full render
+	StageOptions stages.ProcessOptions
 }
 
-func (t *Template) TemplateChart(chartPath, valuesFile, outputPath string, fullRender, excludeDefaultValues bool) {
-	values := t.loadValuesFile(chartPath, valuesFile, excludeDefaultValues)
-	for _, chartTemplate := range t.discoverTemplates(chartPath) {
+// RenderedTemplate is a chart template rendered with the chart values
+type RenderedTemplate struct {
+	Name   string
+	Buffer *bytes.Buffer
+}
+
+func (t *Template) TemplateChart(chartPath, valuesFile, outputPath string, fullRender, excludeDefaultValues bool) error {
+	renderedTemplates, err := t.RenderChart(chartPath, valuesFile, fullRender, excludeDefaultValues)
+	if err != nil {
+		return err
+	}
+	for _, renderedTemplate := range renderedTemplates {
+		err = t.writeTemplateFile(outputPath, renderedTemplate.Name, renderedTemplate.Buffer)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderChart renders the chart templates in memory, valuesFile is a comma separated list of values files
+// the merged values used to render the chart are kept in the template Values
+func (t *Template) RenderChart(chartPath, valuesFile string, fullRender, excludeDefaultValues bool) ([]RenderedTemplate, error) {
+	values, err := t.loadValuesFile(chartPath, valuesFile, excludeDefaultValues)
+	if err != nil {
+		return nil, err
+	}
+	chartOwner, err := t.chartOwner(chartPath, values)
+	if err != nil {
+		return nil, err
+	}
+	chartTemplates, err := t.discoverTemplates(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	renderedTemplates := make([]RenderedTemplate, 0)
+	for _, chartTemplate := range chartTemplates {
 		log.Debugf("Found chart template: %v", chartTemplate)
 
-		buffer := t.templateFile(chartPath, chartTemplate.Name(), values)
+		buffer, err := t.templateFile(chartPath, chartTemplate.Name(), values)
+		if err != nil {
+			return nil, err
+		}
+
+		if chartOwner != nil {
+			buffer, err = t.stampOwner(buffer, *chartOwner)
+			if err != nil {
+				return nil, fmt.Errorf("template '%v': %w", chartTemplate.Name(), err)
+			}
+		}
 
 		if fullRender != false {
-			buffer = t.fullRender(buffer)
+			buffer, err = t.fullRender(buffer)
+			if err != nil {
+				return nil, fmt.Errorf("template '%v': %w", chartTemplate.Name(), err)
+			}
+		}
+		renderedTemplates = append(renderedTemplates, RenderedTemplate{Name: chartTemplate.Name(), Buffer: buffer})
+	}
+	return renderedTemplates, nil
+}
+
+// WriteManifests writes the rendered templates as a single multi document yaml stream, like helm template
+// each template document is preceded by its source template, the empty templates are left out
+func WriteManifests(w io.Writer, renderedTemplates []RenderedTemplate) error {
+	for _, renderedTemplate := range renderedTemplates {
+		content := bytes.TrimSpace(renderedTemplate.Buffer.Bytes())
+		content = bytes.TrimSpace(bytes.TrimPrefix(content, []byte("---")))
+		if len(content) == 0 {
+			continue
+		}
+		_, err := fmt.Fprintf(w, "---\n# Source: %v\n%s\n", renderedTemplate.Name, content)
+		if err != nil {
+			return fmt.Errorf("failed to write the manifests: %w", err)
 		}
-		t.writeTemplateFile(outputPath, chartTemplate.Name(), buffer)
 	}
+	return nil
 }
 
-func (t Template) discoverTemplates(chartPath string) []os.DirEntry {
+func (t Template) discoverTemplates(chartPath string) ([]os.DirEntry, error) {
 	chartTemplates, err := os.ReadDir(path.Join(chartPath, TemplatesFolder))
 	if err != nil {
-		log.Fatalf("Error dicovering Chart templates: %v", err)
+		return nil, fmt.Errorf("error dicovering Chart templates: %w", err)
 	}
 
-	return chartTemplates
+	return chartTemplates, nil
 }
 
-func (t Template) templateFile(chartPath, chartTemplate string, values Values) *bytes.Buffer {
+func (t Template) templateFile(chartPath, chartTemplate string, values Values) (*bytes.Buffer, error) {
 	// Create a named template for each file
 	templatePath := path.Join(chartPath, TemplatesFolder, chartTemplate)
 	tpl := template.New(chartTemplate).Funcs(template.FuncMap(sprig.FuncMap()))
 	tpl, err := tpl.ParseFiles(templatePath)
 	if err != nil {
-		log.Fatalf("Error in parsing: %v", err)
+		return nil, fmt.Errorf("error in parsing: %w", err)
 	}
 
 	buffer := new(bytes.Buffer)
 	err = tpl.Execute(buffer, values)
 	if err != nil {
-		log.Fatalf("Error templating: %v", err)
+		return nil, fmt.Errorf("error templating: %w", err)
 	}
-	return buffer
+	return buffer, nil
 }
 
-func (t *Template) fullRender(buffer *bytes.Buffer) *bytes.Buffer {
+func (t *Template) fullRender(buffer *bytes.Buffer) (*bytes.Buffer, error) {
 	m := manifest.NewManifest{}
-	manifests := m.Decode(buffer)
+	manifests, err := m.Decode(buffer)
+	if err != nil {
+		return nil, err
+	}
 	buffer.Reset()
 	for _, newManifest := range manifests {
+		var rendered interface{}
 		switch newManifest.Kind {
 		case m.Application.GetKind():
-			buffer.Write(t.MarshalYAML(m.Application.Load(newManifest).Manifest))
+			application, err := m.Application.Load(newManifest)
+			if err != nil {
+				return nil, err
+			}
+			rendered = application.Manifest
 		case m.Pipeline.GetKind():
-			buffer.Write(t.MarshalYAML(m.Pipeline.Load(newManifest).Manifest))
+			p := &pipeline.Pipeline{StageOptions: t.StageOptions}
+			pipeline, err := p.Load(newManifest)
+			if err != nil {
+				return nil, err
+			}
+			rendered = pipeline.Manifest
+		default:
+			continue
+		}
+		renderedYAML, err := t.MarshalYAML(rendered)
+		if err != nil {
+			return nil, err
 		}
+		// Keep the documents of the template apart
+		if buffer.Len() != 0 {
+			buffer.WriteString("---\n")
+		}
+		buffer.Write(renderedYAML)
 	}
-	return buffer
+	return buffer, nil
 }
 
-func (t *Template) writeTemplateFile(outputPath, chartTemplate string, buffer *bytes.Buffer) {
-	t.Mkdir(path.Join(outputPath), FilePerm)
-	t.WriteFile(path.Join(outputPath, chartTemplate), buffer.Bytes(), FilePerm)
+func (t *Template) writeTemplateFile(outputPath, chartTemplate string, buffer *bytes.Buffer) error {
+	err := t.Mkdir(path.Join(outputPath), FilePerm)
+	if err != nil {
+		return err
+	}
+	return t.WriteFile(path.Join(outputPath, chartTemplate), buffer.Bytes(), FilePerm)
 }
@@ -1,11 +1,15 @@
 package chart
 
 import (
+	"bytes"
 	"github.com/go-test/deep"
 	"os"
 	"path"
+	"strings"
 	"swinch/domain/datastore"
+	"swinch/domain/manifest"
 	_ "swinch/testing"
+	"swinch/version"
 	"testing"
 )
 
@@ -46,6 +50,8 @@ var fullRender = renderTest{
 }
 
 func TestTemplateChart(t *testing.T) {
+	// the control manifests are owned by the test version
+	useVersion(t, "test")
 	r := renderTest{}
 	r.runRenderTest(simpleRender, t)
 	r.runRenderTest(optionsRender, t)
@@ -54,7 +60,10 @@ func TestTemplateChart(t *testing.T) {
 
 func (r renderTest) runRenderTest(test renderTest, t *testing.T){
 	t.Run(test.outputPath, func(t *testing.T) {
-		control, render := r.renderer(test)
+		control, render, err := r.renderer(test)
+		if err != nil {
+			t.Fatal(err)
+		}
 		if len(control) == 0 || len(render) == 0 {
 			t.Error("Failed to load test params.")
 		}
@@ -64,24 +73,69 @@ func (r renderTest) runRenderTest(test renderTest, t *testing.T){
 	})
 }
 
-func (r renderTest) renderer(test renderTest) ([]byte, []byte){
+func (r renderTest) renderer(test renderTest) ([]byte, []byte, error){
 	r = test
 	tp := Template{}
 	d := datastore.Datastore{}
 
-	outputPath := d.CreateTmpFolder()
+	outputPath, err := d.CreateTmpFolder()
+	if err != nil {
+		return nil, nil, err
+	}
 	defer os.RemoveAll(outputPath)
 
 	r.outputPath = path.Join(outputPath + r.outputPath)
-	tp.TemplateChart(
+	err = tp.TemplateChart(
 		r.chartPath,
 		r.valuesFile,
 		r.outputPath,
 		r.fullRender,
 		r.excludeDefaultValues)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	control, err := d.ReadFile(r.control)
+	if err != nil {
+		return nil, nil, err
+	}
+	render, err := d.ReadFile(path.Join(r.outputPath + "/pipeline.yaml"))
 
-	control := d.ReadFile(r.control)
-	render := d.ReadFile(path.Join(r.outputPath + "/pipeline.yaml"))
+	return control, render, err
+}
+
+func TestWriteManifests(t *testing.T) {
+	useVersion(t, "test")
+	tp := Template{}
+	renderedTemplates, err := tp.RenderChart(simpleRender.chartPath, simpleRender.valuesFile, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renderedTemplates = append(renderedTemplates, RenderedTemplate{Name: "empty.yaml", Buffer: bytes.NewBufferString("---\n")})
 
-	return control, render
+	stream := new(bytes.Buffer)
+	if err = WriteManifests(stream, append(renderedTemplates, renderedTemplates...)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stream.String(), "empty.yaml") {
+		t.Error("expected the empty template to be left out")
+	}
+
+	m := manifest.Manifest{}
+	manifests, err := m.Decode(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 2 {
+		t.Errorf("expected 2 manifests in the stream, got %d", len(manifests))
+	}
+}
+
+// useVersion sets the swinch version stamped in the rendered manifests until the test is done
+func useVersion(t *testing.T, v string) {
+	previous := version.Version
+	version.Version = v
+	t.Cleanup(func() {
+		version.Version = previous
+	})
 }
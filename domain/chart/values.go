@@ -13,9 +13,9 @@ governing permissions and limitations under the License.
 package chart
 
 import (
+	"errors"
+	"fmt"
 	"github.com/imdario/mergo"
-	log "github.com/sirupsen/logrus"
-	"os"
 	"path"
 	"strings"
 	"swinch/domain/datastore"
@@ -25,34 +25,42 @@ type Values struct {
 	Values map[interface{}]interface{}
 }
 
-func (v *Values) loadValuesFile(chartPath, valuesFilePaths string, excludeDefaultValues bool) Values {
-	paths := v.getPaths(chartPath, valuesFilePaths, excludeDefaultValues)
+func (v *Values) loadValuesFile(chartPath, valuesFilePaths string, excludeDefaultValues bool) (Values, error) {
+	paths, err := v.getPaths(chartPath, valuesFilePaths, excludeDefaultValues)
+	if err != nil {
+		return Values{}, err
+	}
 	d := datastore.Datastore{}
 	for _, valuesFilePath := range paths {
-		_, err := os.Stat(valuesFilePath)
-		os.IsNotExist(err)
-		values := d.UnmarshalYAMLValues(d.ReadFile(valuesFilePath))
+		valuesFile, err := d.ReadFile(valuesFilePath)
+		if err != nil {
+			return Values{}, err
+		}
+		values, err := d.UnmarshalYAMLValues(valuesFile)
+		if err != nil {
+			return Values{}, fmt.Errorf("values file '%v': %w", valuesFilePath, err)
+		}
 		if err = mergo.Merge(&v.Values, values, mergo.WithOverride); err != nil {
-			log.Fatalf(err.Error())
+			return Values{}, err
 		}
 	}
-	return *v
+	return *v, nil
 }
 
-func (v Values) getPaths(chartPath, cliPaths string, excludeDefaultValues bool) []string {
+func (v Values) getPaths(chartPath, cliPaths string, excludeDefaultValues bool) ([]string, error) {
 	paths := make([]string, 0)
 
 	if excludeDefaultValues == false {
 		paths = append(paths, path.Join(chartPath, "/values.yaml"))
 	}
 
-	if  len(cliPaths) > 0 {
+	if len(cliPaths) > 0 {
 		paths = append(paths, strings.Split(cliPaths, ",")...)
 	}
 
 	if len(paths) == 0 {
-		log.Fatalf("Failed to find values file paths")
+		return nil, errors.New("failed to find values file paths")
 	}
 
-	return paths
+	return paths, nil
 }
@@ -8,7 +8,10 @@ import (
 
 func TestLoadValuesFile(t *testing.T) {
 	v := Values{}
-	result := v.loadValuesFile("test/charts/test_values", "test/values/values1.yaml,test/values/values2.yaml", false)
+	result, err := v.loadValuesFile("test/charts/test_values", "test/values/values1.yaml,test/values/values2.yaml", false)
+	if err != nil {
+		t.Fatal(err)
+	}
 	values := Values{
 		Values: map[interface{}]interface{}{
 			"test": map[string]interface{}{
@@ -15,11 +15,13 @@ package datastore
 import (
 	"bytes"
 	"encoding/json"
-	log "github.com/sirupsen/logrus"
+	"fmt"
 	"gopkg.in/yaml.v3"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 )
 
 const (
@@ -29,130 +31,243 @@ const (
 type Datastore struct {
 }
 
-// LoadYAMLFiles receives a folder path, reads all yaml files, merges them in a buffer and returns it
-func (d Datastore) LoadYAMLFiles(path string) *bytes.Buffer {
-	yamlFilesBuffer := new(bytes.Buffer)
+// Stdin is read for the "-" manifest path
+var Stdin io.Reader = os.Stdin
+
+// StdinPath names the documents read from Stdin
+const StdinPath = "<stdin>"
+
+// YAMLFile is a yaml file found by ReadYAMLFiles
+type YAMLFile struct {
+	Path string
+	Data []byte
+}
+
+// ReadYAMLFiles receives files, folders or glob patterns and returns all the yaml files found, empty files are left out
+// the folders are read recursively with recursive, "-" reads the yaml documents from Stdin
+func (d Datastore) ReadYAMLFiles(paths []string, recursive bool) ([]YAMLFile, error) {
+	yamlFiles := make([]YAMLFile, 0)
+	stdinRead := false
+
+	for _, path := range paths {
+		if path == "-" {
+			if stdinRead {
+				return nil, fmt.Errorf("stdin can only be read once")
+			}
+			stdinRead = true
+			byteData, err := io.ReadAll(Stdin)
+			if err != nil {
+				return nil, fmt.Errorf("error reading stdin: %w", err)
+			}
+			yamlFiles = appendYAMLFile(yamlFiles, StdinPath, byteData)
+			continue
+		}
+
+		files, err := d.findYAMLFiles(path, recursive)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			byteData, err := d.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			yamlFiles = appendYAMLFile(yamlFiles, file, byteData)
+		}
+	}
+
+	if len(yamlFiles) == 0 {
+		return nil, fmt.Errorf("no yaml documents found in: %v", strings.Join(paths, ", "))
+	}
+	return yamlFiles, nil
+}
+
+// findYAMLFiles returns the yaml files of a file, folder or glob pattern, sorted by path
+func (d Datastore) findYAMLFiles(path string, recursive bool) ([]string, error) {
+	matches := []string{path}
+	if hasGlobMeta(path) {
+		var err error
+		matches, err = filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("bad file pattern %v: %w", path, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match the pattern: %v", path)
+		}
+	}
+
+	files := make([]string, 0)
+	for _, match := range matches {
+		location, err := os.Stat(match)
+		if err != nil {
+			return nil, err
+		}
+		if !location.IsDir() {
+			if isYAMLFile(match) {
+				files = append(files, match)
+			} else if match == path {
+				return nil, fmt.Errorf("not a yaml file: %v", path)
+			}
+			continue
+		}
+
+		err = filepath.WalkDir(match, func(file string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if file != match && !recursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if isYAMLFile(file) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error reading folder %v: %w", match, err)
+		}
+	}
+	return files, nil
+}
+
+func appendYAMLFile(yamlFiles []YAMLFile, path string, byteData []byte) []YAMLFile {
+	if len(bytes.TrimSpace(byteData)) == 0 {
+		return yamlFiles
+	}
+	return append(yamlFiles, YAMLFile{Path: path, Data: byteData})
+}
+
+func isYAMLFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// ReadJSONFiles receives a file or folder path and returns the content of every json file found, non recursive
+func (d Datastore) ReadJSONFiles(path string) ([][]byte, error) {
+	jsonFiles := make([][]byte, 0)
 
 	switch location, err := os.Stat(path); {
 	case err != nil:
-		log.Fatalf("error: %v", err)
+		return nil, err
 	case location.IsDir() == true:
 		files, err := os.ReadDir(path)
 		if err != nil {
-			log.Fatalf("error: %v", err)
+			return nil, err
 		}
 
 		for _, file := range files {
-			if file.IsDir() {
-				continue
-			} else {
-				if filepath.Ext(file.Name()) == ".yaml" {
-					yamlFilesBuffer.Write(d.ReadFile(path + "/" + file.Name()))
-				} else {
-					continue
+			if !file.IsDir() && filepath.Ext(file.Name()) == ".json" {
+				byteData, err := d.ReadFile(filepath.Join(path, file.Name()))
+				if err != nil {
+					return nil, err
 				}
+				jsonFiles = append(jsonFiles, byteData)
 			}
 		}
 	case location.IsDir() == false:
-		if filepath.Ext(path) == ".yaml" {
-			yamlFilesBuffer.Write(d.ReadFile(path))
+		if filepath.Ext(path) == ".json" {
+			byteData, err := d.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			jsonFiles = append(jsonFiles, byteData)
 		} else {
-			log.Errorf("Please provide an yaml file")
+			return nil, fmt.Errorf("not a json file: %v", path)
 		}
 	}
 
-	return yamlFilesBuffer
-}
-
-func (d Datastore) WriteJSON(data interface{}, outputPath string) {
-	byteData := d.MarshalJSON(data)
-	d.WriteFile(outputPath, byteData, FilePerm)
+	if len(jsonFiles) == 0 {
+		return nil, fmt.Errorf("no json files found in path: %v", path)
+	}
+	return jsonFiles, nil
 }
 
-func (d Datastore) WriteJSONTmp(data interface{}) (filePath string) {
-	byteData := d.MarshalJSON(data)
-	return d.writeTmpFile(byteData)
+func (d Datastore) WriteJSON(data interface{}, outputPath string) error {
+	byteData, err := d.MarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	return d.WriteFile(outputPath, byteData, FilePerm)
 }
 
-func (d Datastore) WriteYAML(data interface{}, outputPath string) {
-	byteData := d.MarshalYAML(data)
-	d.WriteFile(outputPath, byteData, FilePerm)
+func (d Datastore) WriteYAML(data interface{}, outputPath string) error {
+	byteData, err := d.MarshalYAML(data)
+	if err != nil {
+		return err
+	}
+	return d.WriteFile(outputPath, byteData, FilePerm)
 }
 
-func (d *Datastore) MarshalJSON(data interface{}) []byte {
+func (d *Datastore) MarshalJSON(data interface{}) ([]byte, error) {
 	byteData, err := json.MarshalIndent(data, "", " ")
 	if err != nil {
-		log.Fatalf("Failed to marshal JSON:  %v", err)
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
 	}
-	return byteData
+	return byteData, nil
 }
 
-func (d *Datastore) MarshalYAML(data interface{}) []byte {
+func (d *Datastore) MarshalYAML(data interface{}) ([]byte, error) {
 	byteData := new(bytes.Buffer)
 	yamlEncoder := yaml.NewEncoder(byteData)
 	yamlEncoder.SetIndent(2)
 	err := yamlEncoder.Encode(&data)
 	if err != nil {
-		log.Fatalf("Error marshal YAML:  %v", err)
+		return nil, fmt.Errorf("failed to marshal YAML: %w", err)
 	}
-	return byteData.Bytes()
+	return byteData.Bytes(), nil
 }
 
-func (d *Datastore) UnmarshalYAMLValues(byteData []byte) map[interface{}]interface{} {
+func (d *Datastore) UnmarshalYAMLValues(byteData []byte) (map[interface{}]interface{}, error) {
 	mapData := new(map[interface{}]interface{})
 	err := yaml.Unmarshal(byteData, &mapData)
 	if err != nil {
-		log.Fatalf("Failed to unmarshall: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal values: %w", err)
 	}
-	return *mapData
+	return *mapData, nil
 }
 
 // Utils
 
-func (d *Datastore) CreateTmpFolder() string {
+func (d *Datastore) CreateTmpFolder() (string, error) {
 	w, err := os.MkdirTemp("", "tempfolder")
 	if err != nil {
-		log.Fatalf("Failed to create the temp folder: %v", err)
-	}
-	return w
-}
-
-func (d *Datastore) writeTmpFile(byteData []byte) string {
-	w, err := os.CreateTemp("" /* /tmp dir. */, "tempfile")
-	if err != nil {
-		log.Fatalf("Failed to create the temp file: %v", err)
-	}
-	bytesWrite, errWrite := w.Write(byteData)
-	if errWrite != nil || bytesWrite == 0 {
-		log.Fatalf("Failed to write tmp file: %v", errWrite)
+		return "", fmt.Errorf("failed to create the temp folder: %w", err)
 	}
-	return w.Name()
+	return w, nil
 }
 
-func (d Datastore) ReadFile(filePath string) []byte {
+func (d Datastore) ReadFile(filePath string) ([]byte, error) {
 	byteData, err := os.ReadFile(filePath)
 	if err != nil {
-		log.Fatalf("Error reading file in path: %v, %v", filePath, err)
+		return nil, fmt.Errorf("error reading file in path: %v, %w", filePath, err)
 	}
-	return byteData
+	return byteData, nil
 }
 
-func (d Datastore) WriteFile(outputPath string, byteData []byte, perm int) {
+func (d Datastore) WriteFile(outputPath string, byteData []byte, perm int) error {
 	filePath := path.Join(outputPath)
 	err := os.WriteFile(filePath, byteData, os.FileMode(perm))
 	if err != nil {
-		log.Fatalf("Failed to write:  %v in path: %v", err, filePath)
-		log.Debugf("Writing: %v", byteData)
+		return fmt.Errorf("failed to write in path: %v, %w", filePath, err)
 	}
+	return nil
 }
 
-func (d Datastore) Mkdir(path string, perm int) {
+func (d Datastore) Mkdir(path string, perm int) error {
 	if _, errStat := os.Stat(path); os.IsNotExist(errStat) {
 		err := os.MkdirAll(path, os.FileMode(perm))
 		if err != nil {
-			log.Fatalf("Error mkdir:  %v", err)
+			return fmt.Errorf("error mkdir: %w", err)
 		}
 	}
+	return nil
 }
 
 func (d Datastore) FileExists(path string) bool {
@@ -0,0 +1,134 @@
+package datastore
+
+import (
+	"github.com/go-test/deep"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadYAMLFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml":        "kind: a",
+		"b.yml":         "---\nkind: b\n",
+		"notes.txt":     "kind: txt\n",
+		"nested/c.yaml": "kind: c\n---\nkind: d\n",
+	})
+
+	nested := filepath.Join(dir, "nested", "c.yaml")
+	tests := []struct {
+		name      string
+		paths     []string
+		recursive bool
+		want      []string
+	}{
+		{"directory", []string{dir}, false, []string{filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yml")}},
+		{"recursive", []string{dir}, true, []string{filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yml"), nested}},
+		{"glob", []string{filepath.Join(dir, "*.yml")}, false, []string{filepath.Join(dir, "b.yml")}},
+		{"several paths", []string{nested, filepath.Join(dir, "a.yaml")}, false, []string{nested, filepath.Join(dir, "a.yaml")}},
+	}
+	for _, test := range tests {
+		files, err := Datastore{}.ReadYAMLFiles(test.paths, test.recursive)
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		paths := make([]string, 0)
+		for _, file := range files {
+			paths = append(paths, file.Path)
+		}
+		if diff := deep.Equal(paths, test.want); diff != nil {
+			t.Errorf("%v: %v", test.name, diff)
+		}
+	}
+}
+
+func TestReadYAMLFilesStdin(t *testing.T) {
+	defer func(stdin io.Reader) { Stdin = stdin }(Stdin)
+	Stdin = strings.NewReader("kind: stdin\n")
+
+	files, err := Datastore{}.ReadYAMLFiles([]string{"-"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(files, []YAMLFile{{Path: StdinPath, Data: []byte("kind: stdin\n")}}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestReadYAMLFilesErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"notes.txt": "kind: txt\n"})
+	defer func(stdin io.Reader) { Stdin = stdin }(Stdin)
+	Stdin = strings.NewReader("kind: stdin\n")
+
+	for _, paths := range [][]string{
+		{filepath.Join(dir, "notes.txt")},
+		{filepath.Join(dir, "*.yaml")},
+		{dir},
+		{"-", "-"},
+	} {
+		if _, err := (Datastore{}).ReadYAMLFiles(paths, true); err == nil {
+			t.Errorf("expected an error loading %v", paths)
+		}
+	}
+}
+
+func TestReadJSONFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.json":        `{"name": "a"}`,
+		"notes.txt":     "b",
+		"nested/c.json": `{"name": "c"}`,
+	})
+
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"directory", dir, []string{`{"name": "a"}`}},
+		{"file", filepath.Join(dir, "nested", "c.json"), []string{`{"name": "c"}`}},
+	}
+	for _, test := range tests {
+		files, err := Datastore{}.ReadJSONFiles(test.path)
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		contents := make([]string, 0)
+		for _, file := range files {
+			contents = append(contents, string(file))
+		}
+		if diff := deep.Equal(contents, test.want); diff != nil {
+			t.Errorf("%v: %v", test.name, diff)
+		}
+	}
+
+	errors := map[string]string{
+		"not json":      filepath.Join(dir, "notes.txt"),
+		"no json files": filepath.Join(dir, "empty"),
+		"missing":       filepath.Join(dir, "missing.json"),
+	}
+	if err := os.Mkdir(filepath.Join(dir, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, path := range errors {
+		if _, err := (Datastore{}).ReadJSONFiles(path); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("%v: expected an error naming %v, got %v", name, path, err)
+		}
+	}
+}
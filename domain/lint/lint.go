@@ -0,0 +1,293 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strconv"
+	"strings"
+	"swinch/domain/application"
+	"swinch/domain/datastore"
+	"swinch/domain/manifest"
+	"swinch/domain/pipeline"
+	"swinch/domain/schema"
+	"swinch/domain/stages"
+)
+
+// Rules reported by Lint
+const (
+	InvalidManifest       = "invalid-manifest"
+	SchemaMismatch        = "schema"
+	UnknownStageType      = "unknown-stage-type"
+	UnknownKey            = "unknown-key"
+	MissingRequisiteStage = "missing-requisite-stage"
+	DependencyCycle       = "dependency-cycle"
+	DeployWithoutBake     = "deploy-without-bake"
+	DuplicateStageName    = "duplicate-stage-name"
+	EmptyAccount          = "empty-account"
+	InvalidIfStageFails   = "invalid-if-stage-fails"
+)
+
+const (
+	TextFormat = "text"
+	JSONFormat = "json"
+)
+
+var Formats = []string{TextFormat, JSONFormat}
+
+var ifStageFailsOptions = []string{stages.HaltPipeline, stages.HaltBranch, stages.HaltBranchAndFail, stages.IgnoreStageFailure}
+
+// Finding is a problem found in a manifest, Stage is set for the stage rules
+type Finding struct {
+	Rule     string `json:"rule"`
+	Manifest string `json:"manifest"`
+	Stage    string `json:"stage,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (f Finding) String() string {
+	if f.Stage != "" {
+		return fmt.Sprintf("%v: stage '%v': %v [%v]", f.Manifest, f.Stage, f.Message, f.Rule)
+	}
+	return fmt.Sprintf("%v: %v [%v]", f.Manifest, f.Message, f.Rule)
+}
+
+// Lint checks every manifest with every rule and returns all the findings, with the schema errors of the manifests if any
+// the manifests passing the rules are loaded as on apply, with the same stage options, to report the remaining errors
+// the schema errors are reported with the first manifest of their name, the errors of no manifest are reported last
+func Lint(manifests []manifest.Manifest, invalid *schema.ValidationError, options stages.ProcessOptions) []Finding {
+	schemaFindings := make(map[string][]Finding)
+	schemaNames := make([]string, 0)
+	if invalid != nil {
+		for _, fieldError := range invalid.Errors {
+			// the ifStageFails values are reported by the InvalidIfStageFails rule with the stage name
+			if strings.HasSuffix(fieldError.Path, ".ifStageFails") {
+				continue
+			}
+			location := fmt.Sprintf("line %d", fieldError.Line)
+			if fieldError.File != "" {
+				location = fmt.Sprintf("%v:%d", fieldError.File, fieldError.Line)
+			}
+			if _, ok := schemaFindings[fieldError.Manifest]; !ok {
+				schemaNames = append(schemaNames, fieldError.Manifest)
+			}
+			schemaFindings[fieldError.Manifest] = append(schemaFindings[fieldError.Manifest], Finding{
+				Rule: SchemaMismatch, Manifest: fieldError.Manifest, Message: fmt.Sprintf("%v: %v: %v", location, fieldError.Path, fieldError.Message),
+			})
+		}
+	}
+
+	findings := make([]Finding, 0)
+	reported := make(map[string]bool)
+	for _, m := range manifests {
+		manifestFindings := make([]Finding, 0)
+		if !reported[m.Name()] {
+			manifestFindings = append(manifestFindings, schemaFindings[m.Name()]...)
+			reported[m.Name()] = true
+		}
+		if m.Kind == pipeline.Kind {
+			manifestFindings = append(manifestFindings, lintPipeline(m, options)...)
+		}
+		// the schema errors of a name can be in any of the manifests with this name, none of them is loaded
+		if len(manifestFindings) == 0 && len(schemaFindings[m.Name()]) == 0 {
+			if err := load(m, options); err != nil {
+				manifestFindings = append(manifestFindings, Finding{Rule: InvalidManifest, Manifest: m.Name(), Message: err.Error()})
+			}
+		}
+		findings = append(findings, manifestFindings...)
+	}
+	for _, name := range schemaNames {
+		if !reported[name] {
+			findings = append(findings, schemaFindings[name]...)
+		}
+	}
+	return findings
+}
+
+func load(m manifest.Manifest, options stages.ProcessOptions) error {
+	switch m.Kind {
+	case application.Kind:
+		a := &application.Application{}
+		_, err := a.Load(m)
+		return err
+	case pipeline.Kind:
+		p := &pipeline.Pipeline{StageOptions: options}
+		_, err := p.Load(m)
+		return err
+	default:
+		return fmt.Errorf("unknown manifest Kind: %v", m.Kind)
+	}
+}
+
+// lintPipeline runs the stage rules on the stages of a pipeline manifest
+// the stage refIds are the stage positions, as set when the pipeline is loaded
+func lintPipeline(m manifest.Manifest, options stages.ProcessOptions) []Finding {
+	findings := make([]Finding, 0)
+	p, err := decodePipeline(m)
+	if err != nil {
+		return append(findings, Finding{Rule: InvalidManifest, Manifest: m.Name(), Message: err.Error()})
+	}
+	report := func(rule, stage, format string, args ...interface{}) {
+		findings = append(findings, Finding{Rule: rule, Manifest: m.Name(), Stage: stage, Message: fmt.Sprintf(format, args...)})
+	}
+
+	ss := stages.Stages{}
+	ss.GetTypes()
+	names := make(map[string]int)
+	for i, stage := range p.Spec.Stages {
+		stageName := fmt.Sprint(stage["name"])
+		stageType := fmt.Sprint(stage["type"])
+
+		// The stages referenced by name are checked by their refIds
+		if err := pipeline.ResolveStageNames(stage, p.Spec.Stages); err != nil {
+			report(MissingRequisiteStage, stageName, "%v", err)
+		}
+
+		if first, ok := names[stageName]; ok {
+			report(DuplicateStageName, stageName, "stage name already used by stage %d", first)
+		} else {
+			names[stageName] = i + 1
+		}
+
+		if s, ok := ss.GetType(stages.StageType(stageType), options.PassThrough); !ok {
+			report(UnknownStageType, stageName, "unknown stage type '%v'", stageType)
+		} else if strict(stage, options) {
+			for _, key := range stages.UnknownKeys(s, stage) {
+				report(UnknownKey, stageName, "%v", key)
+			}
+		}
+
+		for _, refId := range stringList(stage["requisiteStageRefIds"]) {
+			if _, ok := stageIndex(refId, p.Spec.Stages); !ok {
+				report(MissingRequisiteStage, stageName, "requisiteStageRefIds points at missing stage '%v'", refId)
+			}
+		}
+
+		if stages.StageType(stageType) == stages.DeployManifestType {
+			if message := bakeProblem(stage, p.Spec.Stages); message != "" {
+				report(DeployWithoutBake, stageName, "%v", message)
+			}
+		}
+
+		if stages.AccountStageTypes[stages.StageType(stageType)] && strings.TrimSpace(fmt.Sprint(valueOrEmpty(stage["account"]))) == "" {
+			report(EmptyAccount, stageName, "%v stage has an empty account", stageType)
+		}
+
+		if ifStageFails, ok := stage["ifStageFails"]; ok && !validIfStageFails(fmt.Sprint(ifStageFails)) {
+			report(InvalidIfStageFails, stageName, "invalid ifStageFails '%v', expected one of: %v", ifStageFails, strings.Join(ifStageFailsOptions, ", "))
+		}
+	}
+	if err := pipeline.CheckCycles(p.Spec.Stages); err != nil {
+		findings = append(findings, Finding{Rule: DependencyCycle, Manifest: m.Name(), Message: err.Error()})
+	}
+	return findings
+}
+
+func decodePipeline(m manifest.Manifest) (pipeline.Manifest, error) {
+	d := datastore.Datastore{}
+	p := pipeline.Manifest{}
+	manifestYAML, err := d.MarshalYAML(m)
+	if err != nil {
+		return p, err
+	}
+	if err = yaml.Unmarshal(manifestYAML, &p); err != nil {
+		return p, fmt.Errorf("error loading pipeline manifest: %w", err)
+	}
+	return p, nil
+}
+
+// bakeProblem returns why a deploy stage is not bound to a bake stage, the bake is bound with bakeStageRefIds or the first requisiteStageRefIds
+func bakeProblem(stage map[string]interface{}, allStages []map[string]interface{}) string {
+	refId := fmt.Sprint(valueOrEmpty(stage["bakeStageRefIds"]))
+	if refId == "" {
+		requisiteStageRefIds := stringList(stage["requisiteStageRefIds"])
+		if len(requisiteStageRefIds) == 0 {
+			return "deploy stage has no requisiteStageRefIds to bind the bake stage"
+		}
+		refId = requisiteStageRefIds[0]
+	}
+	index, ok := stageIndex(refId, allStages)
+	if !ok {
+		return fmt.Sprintf("deploy stage is bound to missing bake stage '%v'", refId)
+	}
+	if stageType := fmt.Sprint(allStages[index]["type"]); stages.StageType(stageType) != stages.BakeManifestType {
+		return fmt.Sprintf("deploy stage is bound to stage '%v' of type '%v', expected a %v stage", allStages[index]["name"], stageType, stages.BakeManifestType)
+	}
+	return ""
+}
+
+// stageIndex returns the index of the stage with a refId, the refIds are the stage positions starting from 1
+func stageIndex(refId string, allStages []map[string]interface{}) (int, bool) {
+	index, err := strconv.Atoi(refId)
+	if err != nil || index < 1 || index > len(allStages) {
+		return 0, false
+	}
+	return index - 1, true
+}
+
+// strict returns true if the unknown keys of a stage fail it, the stage strict key overrides the options
+func strict(stage map[string]interface{}, options stages.ProcessOptions) bool {
+	value, ok := stage["strict"]
+	if !ok || value == nil {
+		return !options.Lenient
+	}
+	return fmt.Sprint(value) != "false"
+}
+
+func validIfStageFails(value string) bool {
+	for _, option := range ifStageFailsOptions {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
+
+// stringList returns the items of a decoded YAML list as strings, refIds can be written as numbers
+func stringList(data interface{}) []string {
+	items := make([]string, 0)
+	list, _ := data.([]interface{})
+	for _, item := range list {
+		items = append(items, fmt.Sprint(item))
+	}
+	return items
+}
+
+func valueOrEmpty(value interface{}) interface{} {
+	if value == nil {
+		return ""
+	}
+	return value
+}
+
+// Write prints the findings as text, one per line, or as json
+func Write(w io.Writer, findings []Finding, format string) error {
+	switch format {
+	case JSONFormat:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", " ")
+		return encoder.Encode(findings)
+	case TextFormat:
+		for _, finding := range findings {
+			if _, err := fmt.Fprintln(w, finding); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format '%v', supported formats: %v", format, strings.Join(Formats, ", "))
+	}
+}
@@ -0,0 +1,150 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"github.com/go-test/deep"
+	"swinch/domain/manifest"
+	"swinch/domain/schema"
+	"swinch/domain/stages"
+	_ "swinch/testing"
+	"testing"
+)
+
+const brokenPipeline = `
+apiVersion: spinnaker.adobe.com/alpha1
+kind: Pipeline
+metadata:
+  name: broken
+  application: test
+spec:
+  stages:
+    - name: bake
+      type: bakeManifest
+      requisiteStageRefIds: []
+      templateRendrer: HELM2
+    - name: wait
+      type: wait
+      requisiteStageRefIds: [1]
+      ifStageFails: halt everything
+      waitTime: [30]
+    - name: deploy
+      type: deployManifest
+      requisiteStageRefIds: [2, 7]
+      account: ""
+    - name: bake
+      type: bakeManyfest
+      requisiteStageRefIds: []
+    - name: cleanup
+      type: deleteManifest
+      requisiteStageRefIds: [3]
+    - name: notify
+      type: wait
+      dependsOn: [cleanup, deplo]
+`
+
+func TestLint(t *testing.T) {
+	m := manifest.Manifest{}
+	manifests, err := m.Decode(bytes.NewBufferString(brokenPipeline))
+	var invalid *schema.ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected the schema errors, got %v", err)
+	}
+
+	rules := make([]string, 0)
+	for _, finding := range Lint(manifests, invalid, stages.ProcessOptions{}) {
+		rules = append(rules, finding.Stage+": "+finding.Rule)
+	}
+	expected := []string{
+		": " + SchemaMismatch,
+		"bake: " + UnknownKey,
+		"wait: " + InvalidIfStageFails,
+		"deploy: " + MissingRequisiteStage,
+		"deploy: " + DeployWithoutBake,
+		"deploy: " + EmptyAccount,
+		"bake: " + DuplicateStageName,
+		"bake: " + UnknownStageType,
+		"cleanup: " + EmptyAccount,
+		"notify: " + MissingRequisiteStage,
+	}
+	if diff := deep.Equal(rules, expected); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestLintValid(t *testing.T) {
+	m := manifest.Manifest{}
+	manifests, err := m.GetManifests([]string{"test/manifests/test_template_simple"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findings := Lint(manifests, nil, stages.ProcessOptions{}); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+func TestLintDependencyCycle(t *testing.T) {
+	m := manifest.Manifest{}
+	manifests, err := m.Decode(bytes.NewBufferString(`
+apiVersion: spinnaker.adobe.com/alpha1
+kind: Pipeline
+metadata:
+  name: cycle
+  application: test
+spec:
+  stages:
+    - name: wait
+      type: wait
+      dependsOn: [notify]
+    - name: notify
+      type: wait
+      dependsOn: [wait]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := Lint(manifests, nil, stages.ProcessOptions{})
+	if len(findings) != 1 || findings[0].Rule != DependencyCycle {
+		t.Errorf("expected the dependency cycle, got %v", findings)
+	}
+}
+
+func TestLintSchemaFindings(t *testing.T) {
+	m := manifest.Manifest{}
+	manifests, err := m.Decode(bytes.NewBufferString(`
+apiVersion: spinnaker.adobe.com/alpha1
+kind: Pipeline
+metadata:
+  name: duplicate
+  application: test
+spec:
+  stages: []
+---
+apiVersion: spinnaker.adobe.com/alpha1
+kind: Pipeline
+metadata:
+  name: duplicate
+  application: test
+spec:
+  limitConcurrent: yes please
+  stages: []
+`))
+	var invalid *schema.ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected the schema errors, got %v", err)
+	}
+	invalid.Errors = append(invalid.Errors, schema.FieldError{Line: 1, Manifest: "Pipeline/missing", Path: "metadata", Message: "no manifest"})
+
+	result := make([]string, 0)
+	for _, finding := range Lint(manifests, invalid, stages.ProcessOptions{}) {
+		result = append(result, finding.Manifest+": "+finding.Rule)
+	}
+	expected := []string{
+		"Pipeline/duplicate: " + SchemaMismatch,
+		"Pipeline/duplicate: " + InvalidManifest,
+		"Pipeline/missing: " + SchemaMismatch,
+	}
+	if diff := deep.Equal(result, expected); diff != nil {
+		t.Error(diff)
+	}
+}
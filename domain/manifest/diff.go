@@ -0,0 +1,117 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"swinch/domain/application"
+	"swinch/domain/change"
+	"swinch/domain/pipeline"
+	"swinch/domain/stages"
+)
+
+// rendered is the Spinnaker spec generated from a manifest
+type rendered struct {
+	kind        string
+	application string
+	name        string
+	spec        []byte
+}
+
+// Diff compares the Spinnaker specs generated from two sets of manifests, offline without Spinnaker
+// the changes are sorted by kind, applications first, then by application and name
+func Diff(oldManifests, newManifests []Manifest, options stages.ProcessOptions) (change.Plan, error) {
+	oldSpecs, err := renderAll(oldManifests, options)
+	if err != nil {
+		return change.Plan{}, err
+	}
+	newSpecs, err := renderAll(newManifests, options)
+	if err != nil {
+		return change.Plan{}, err
+	}
+
+	all := make([]rendered, 0)
+	for key, r := range oldSpecs {
+		if _, ok := newSpecs[key]; !ok {
+			all = append(all, r)
+		}
+	}
+	for _, r := range newSpecs {
+		all = append(all, r)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].kind != all[j].kind {
+			return all[i].kind == application.Kind
+		}
+		if all[i].application != all[j].application {
+			return all[i].application < all[j].application
+		}
+		return all[i].name < all[j].name
+	})
+
+	p := change.Plan{}
+	for _, r := range all {
+		key := specKey(r.kind, r.application, r.name)
+		c, err := change.New(r.kind, r.application, r.name, oldSpecs[key].spec, newSpecs[key].spec)
+		if err != nil {
+			return change.Plan{}, err
+		}
+		p.Add(c)
+	}
+	return p, nil
+}
+
+// renderAll loads the manifests and generates their Spinnaker specs, the pipeline stages are expanded by the stage processor
+func renderAll(manifests []Manifest, options stages.ProcessOptions) (map[string]rendered, error) {
+	specs := make(map[string]rendered)
+	for _, m := range manifests {
+		r, err := render(m, options)
+		if err != nil {
+			return nil, err
+		}
+		key := specKey(r.kind, r.application, r.name)
+		if _, ok := specs[key]; ok {
+			return nil, fmt.Errorf("duplicate %v '%v' in application '%v'", r.kind, r.name, r.application)
+		}
+		specs[key] = r
+	}
+	return specs, nil
+}
+
+func render(m Manifest, options stages.ProcessOptions) (rendered, error) {
+	switch m.Kind {
+	case application.Kind:
+		a := &application.Application{}
+		if _, err := a.Load(m); err != nil {
+			return rendered{}, err
+		}
+		spec, err := a.MarshalJSON(a.Spec)
+		return rendered{kind: application.Kind, application: a.Spec.Name, name: a.Spec.Name, spec: spec}, err
+	case pipeline.Kind:
+		p := &pipeline.Pipeline{StageOptions: options}
+		if _, err := p.Load(m); err != nil {
+			return rendered{}, err
+		}
+		// The owner values hash changes with any values change, the owner is left out of the diff
+		p.Spec.ManagedBy = nil
+		spec, err := p.MarshalJSON(p.Spec)
+		return rendered{kind: pipeline.Kind, application: p.Spec.Application, name: p.Spec.Name, spec: spec}, err
+	default:
+		return rendered{}, fmt.Errorf("unknown manifest Kind: %v", m.Kind)
+	}
+}
+
+func specKey(kind, application, name string) string {
+	return fmt.Sprintf("%v/%v/%v", kind, application, name)
+}
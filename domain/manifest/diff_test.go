@@ -0,0 +1,51 @@
+package manifest
+
+import (
+	"swinch/domain/change"
+	"swinch/domain/stages"
+	_ "swinch/testing"
+	"testing"
+)
+
+func loadTestManifests(t *testing.T, path string) []Manifest {
+	m := Manifest{}
+	manifests, err := m.GetManifests([]string{path}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manifests
+}
+
+func TestDiff(t *testing.T) {
+	simple := loadTestManifests(t, "test/manifests/test_template_simple")
+	updated := loadTestManifests(t, "test/manifests/test_template_simple")
+	updated[0].Spec.(map[string]interface{})["spelEvaluator"] = "v3"
+
+	tests := []struct {
+		name   string
+		old    []Manifest
+		new    []Manifest
+		action change.Action
+	}{
+		{"unchanged", simple, simple, change.NoOp},
+		{"created", nil, simple, change.Create},
+		{"deleted", simple, nil, change.Delete},
+		{"updated", simple, updated, change.Update},
+	}
+	for _, test := range tests {
+		p, err := Diff(test.old, test.new, stages.ProcessOptions{})
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		if len(p.Changes) != 1 {
+			t.Fatalf("%v: expected 1 change, got %d", test.name, len(p.Changes))
+		}
+		c := p.Changes[0]
+		if c.Action != test.action {
+			t.Errorf("%v: expected %v, got %v", test.name, test.action, c.Action)
+		}
+		if test.action == change.Update && c.FieldsDiff() != "spelEvaluator: v4 -> v3" {
+			t.Errorf("%v: expected the spelEvaluator change, got %v", test.name, c.FieldsDiff())
+		}
+	}
+}
@@ -14,14 +14,16 @@ package manifest
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 	"io"
 	"swinch/domain/application"
+	"swinch/domain/change"
 	"swinch/domain/datastore"
 	"swinch/domain/pipeline"
+	"swinch/domain/schema"
 )
 
 var Kinds = map[string]string{
@@ -30,9 +32,13 @@ var Kinds = map[string]string{
 }
 
 type M interface {
-	Plan()
-	Apply(bool, bool)
-	Destroy()
+	Change(context.Context) (change.Change, error)
+	Save(context.Context) error
+	Snapshot(context.Context) ([]byte, error)
+	Restore(context.Context, []byte) error
+	Plan(context.Context) error
+	Apply(context.Context, bool, bool) error
+	Destroy(context.Context) error
 }
 
 type NewManifest struct {
@@ -48,34 +54,82 @@ type Manifest struct {
 	Spec       interface{} `yaml:"spec" json:"spec"`
 }
 
-func (m *Manifest) GetManifests(filePath string) []Manifest {
+// GetManifests reads the manifests of files, folders or glob patterns, "-" reads the manifests from stdin
+// the schema errors of all the manifests are reported together in a schema.ValidationError, returned with the manifests
+func (m *Manifest) GetManifests(paths []string, recursive bool) ([]Manifest, error) {
 	d := datastore.Datastore{}
-	return m.Decode(d.LoadYAMLFiles(filePath))
+	files, err := d.ReadYAMLFiles(paths, recursive)
+	if err != nil {
+		return nil, err
+	}
+	manifests := make([]Manifest, 0)
+	invalid := &schema.ValidationError{}
+	for _, file := range files {
+		fileManifests, err := m.Decode(bytes.NewBuffer(file.Data))
+		var validationError *schema.ValidationError
+		if errors.As(err, &validationError) {
+			for _, fieldError := range validationError.Errors {
+				fieldError.File = file.Path
+				invalid.Errors = append(invalid.Errors, fieldError)
+			}
+		} else if err != nil {
+			return nil, fmt.Errorf("%v: %w", file.Path, err)
+		}
+		manifests = append(manifests, fileManifests...)
+	}
+	if len(invalid.Errors) > 0 {
+		return manifests, invalid
+	}
+	return manifests, nil
 }
 
-func (m *Manifest) Decode(buffer *bytes.Buffer) []Manifest {
+// Decode reads the manifests of a yaml stream, validated with the schema of their kind
+// the schema errors of all the manifests are reported together in a schema.ValidationError, returned with the manifests
+func (m *Manifest) Decode(buffer *bytes.Buffer) ([]Manifest, error) {
 	decoder := yaml.NewDecoder(buffer)
 	manifests := make([]Manifest, 0)
+	invalid := &schema.ValidationError{}
 	for {
-		errDecode := decoder.Decode(&m)
-		if m == nil {
-			continue
-		}
+		var document yaml.Node
+		errDecode := decoder.Decode(&document)
 		if errors.Is(errDecode, io.EOF) {
 			break
 		}
 		if errDecode != nil {
-			log.Fatalf("Error reading YAML: %v", errDecode)
+			return nil, fmt.Errorf("error reading YAML: %w", errDecode)
+		}
+		// Empty documents are skipped
+		if len(document.Content) == 0 || document.Content[0].Tag == "!!null" {
+			continue
+		}
+		// Schema validation of the known kinds, reported with the path and line of every error
+		var validationError *schema.ValidationError
+		if err := schema.Validate(&document); errors.As(err, &validationError) {
+			invalid.Errors = append(invalid.Errors, validationError.Errors...)
+		}
+		doc := Manifest{}
+		if err := document.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("error reading YAML: %w", err)
 		}
 		// Basic manifest kind and version validation
-		err := m.validate()
+		err := doc.validate()
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
-		manifests = append(manifests, *m)
+		manifests = append(manifests, doc)
 	}
+	if len(invalid.Errors) > 0 {
+		return manifests, invalid
+	}
+	return manifests, nil
+}
 
-	return manifests
+// Name returns the manifest kind and name, used to report the manifest
+func (m Manifest) Name() string {
+	if metadata, ok := m.Metadata.(map[string]interface{}); ok {
+		return fmt.Sprintf("%v/%v", m.Kind, metadata["name"])
+	}
+	return m.Kind
 }
 
 func (m *Manifest) validate() error {
@@ -0,0 +1,51 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package owner
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"swinch/version"
+)
+
+// Owner marks a pipeline managed by swinch with the chart and values which rendered it
+type Owner struct {
+	Chart        string `yaml:"chart" json:"chart"`
+	ChartVersion string `yaml:"chartVersion" json:"chartVersion"`
+	ValuesHash   string `yaml:"valuesHash" json:"valuesHash"`
+	Version      string `yaml:"version" json:"version"`
+}
+
+// New returns the owner of the manifests rendered from a chart with the values, stamped with the swinch version
+func New(chart, chartVersion string, values interface{}) (Owner, error) {
+	valuesHash, err := HashValues(values)
+	if err != nil {
+		return Owner{}, err
+	}
+	return Owner{Chart: chart, ChartVersion: chartVersion, ValuesHash: valuesHash, Version: version.Version}, nil
+}
+
+// HashValues returns the sha256 of the values, map keys are sorted so equal values have the same hash
+func HashValues(values interface{}) (string, error) {
+	valuesYAML, err := yaml.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash values: %w", err)
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(valuesYAML)), nil
+}
+
+// OwnedBy returns true if the owner is set and rendered by the chart
+func (o *Owner) OwnedBy(chart string) bool {
+	return o != nil && o.Chart == chart
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"swinch/domain/stages"
+)
+
+// specKeys are the Spinnaker pipeline keys managed by swinch or generated by Spinnaker
+var specKeys = map[string]bool{
+	"application":          true,
+	"name":                 true,
+	"disabled":             true,
+	"keepWaitingPipelines": true,
+	"limitConcurrent":      true,
+	"spelEvaluator":        true,
+	"stages":               true,
+	"triggers":             true,
+	// Generated by Spinnaker
+	"id":             true,
+	"index":          true,
+	"updateTs":       true,
+	"lastModifiedBy": true,
+}
+
+// Import gets a pipeline from Spinnaker and converts it to a swinch manifest
+func (p *Pipeline) Import(ctx context.Context, appName, pipeName string) (Manifest, error) {
+	existingPipe, err := p.backend().Get(ctx, appName, pipeName)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if len(existingPipe) == 0 {
+		return Manifest{}, fmt.Errorf("pipeline '%v' not found in application '%v'", pipeName, appName)
+	}
+	return p.ImportJSON(existingPipe)
+}
+
+// ImportAll gets all the pipelines of an application from Spinnaker and converts them to swinch manifests
+func (p *Pipeline) ImportAll(ctx context.Context, appName string) ([]Manifest, error) {
+	pipesJSON, err := p.backend().List(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	pipes := make([]json.RawMessage, 0)
+	err = json.Unmarshal(pipesJSON, &pipes)
+	if err != nil {
+		return nil, fmt.Errorf("error loading pipelines of application '%v': %w", appName, err)
+	}
+	if len(pipes) == 0 {
+		return nil, fmt.Errorf("no pipelines found in application '%v'", appName)
+	}
+
+	manifests := make([]Manifest, 0)
+	for _, pipeJSON := range pipes {
+		manifest, err := p.ImportJSON(pipeJSON)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+// ImportJSON converts a Spinnaker pipeline JSON to a swinch manifest
+func (p *Pipeline) ImportJSON(pipeJSON []byte) (Manifest, error) {
+	err := p.checkSpecKeys(pipeJSON)
+	if err != nil {
+		return Manifest{}, err
+	}
+	spec, err := p.loadSpec(pipeJSON)
+	if err != nil {
+		return Manifest{}, err
+	}
+	p.Manifest = Manifest{
+		ApiVersion: API,
+		Kind:       Kind,
+		Metadata: Metadata{
+			Name:        spec.Name,
+			Application: spec.Application,
+		},
+		Spec: spec,
+	}
+	err = p.importManifest(&p.Manifest, p.StageOptions)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("pipeline '%v': %w", spec.Name, err)
+	}
+
+	// The imported manifest is loaded as on apply, a pipeline swinch can't apply again is not imported
+	imported := Pipeline{StageOptions: p.StageOptions}
+	if _, err = imported.Load(p.Manifest); err != nil {
+		return Manifest{}, fmt.Errorf("imported pipeline can't be applied: %w", err)
+	}
+	return p.Manifest, nil
+}
+
+func (p *Pipeline) checkSpecKeys(pipeJSON []byte) error {
+	spec := make(map[string]interface{})
+	err := json.Unmarshal(pipeJSON, &spec)
+	if err != nil {
+		return fmt.Errorf("error loading spec: %w", err)
+	}
+	for key := range spec {
+		if !specKeys[key] {
+			log.Warnf("Pipeline key '%v' is not supported by swinch, dropping it from the import", key)
+		}
+	}
+	return nil
+}
+
+// importManifest reverts the processManifest expansion on the Spinnaker stages
+func (ps *Processor) importManifest(manifest *Manifest, options stages.ProcessOptions) error {
+	ps.Stages.GetTypes()
+	ps.Options = options
+	ps.Manifest = *manifest
+
+	// Stage importers look up the stages as they were in Spinnaker
+	allStages, err := ps.copyStages()
+	if err != nil {
+		return err
+	}
+	// Spinnaker refIds are replaced by the stage position, same as processManifest generates them
+	refIds := make(map[string]string)
+	for i, stage := range ps.Manifest.Spec.Stages {
+		refIds[fmt.Sprint(stage["refId"])] = strconv.Itoa(i + 1)
+	}
+
+	for i := 0; i < len(ps.Manifest.Spec.Stages); i++ {
+		err = importRequisiteStageRefIds(&ps.Manifest.Spec.Stages[i], refIds)
+		if err != nil {
+			return err
+		}
+		stage, err := ps.Decode(&ps.Manifest.Spec.Stages[i])
+		if err != nil {
+			return err
+		}
+		ps.Stage = stage
+		ps.InitStage = &ps.Manifest.Spec.Stages[i]
+		ps.AllStages = &allStages
+
+		stageType := stages.StageType(ps.Stage.Type)
+		if _, ok := ps.GetType(stageType, ps.Options.PassThrough); !ok {
+			return fmt.Errorf("stage '%v': failed to detect stage type: %v", ps.Stage.Metadata.Name, ps.Stage.Type)
+		}
+
+		ps.Stage.ImportCommon()
+		if importer, ok := ps.GetImporter(stageType); ok {
+			importer.ImportStage(&ps.Stage)
+		}
+	}
+	// The imported stages reference each other by name, inserting a stage in the chart doesn't rewire the pipeline
+	nameReferences(ps.Manifest.Spec.Stages)
+	return nil
+}
+
+// nameReferences replaces the refIds of the imported stages with the names of the referenced stages, dependsOn for requisiteStageRefIds
+// and bakeStage or jobBakeStage for the bake refIds; the refIds of stages with a duplicated name are kept
+func nameReferences(allStages []map[string]interface{}) {
+	counts := make(map[string]int)
+	for _, stage := range allStages {
+		counts[fmt.Sprint(stage["name"])]++
+	}
+	names := make(map[string]string)
+	for i, stage := range allStages {
+		if name := fmt.Sprint(stage["name"]); counts[name] == 1 {
+			names[strconv.Itoa(i+1)] = name
+		}
+	}
+
+	for _, stage := range allStages {
+		requisiteStageRefIds, _ := stage["requisiteStageRefIds"].([]interface{})
+		dependsOn := make([]interface{}, 0)
+		for _, refId := range requisiteStageRefIds {
+			if name, ok := names[fmt.Sprint(refId)]; ok {
+				dependsOn = append(dependsOn, name)
+			}
+		}
+		if len(dependsOn) > 0 && len(dependsOn) == len(requisiteStageRefIds) {
+			stage["dependsOn"] = dependsOn
+			delete(stage, "requisiteStageRefIds")
+		}
+
+		for _, reference := range bakeReferences {
+			nameKey, refIdKey := reference[0], reference[1]
+			refId, ok := stage[refIdKey]
+			if !ok {
+				continue
+			}
+			if name, ok := names[fmt.Sprint(refId)]; ok {
+				stage[nameKey] = name
+				delete(stage, refIdKey)
+			}
+		}
+	}
+}
+
+func importRequisiteStageRefIds(stage *map[string]interface{}, refIds map[string]string) error {
+	requisiteStageRefIds, _ := (*stage)["requisiteStageRefIds"].([]interface{})
+	importedRefIds := make([]interface{}, 0)
+	for _, refId := range requisiteStageRefIds {
+		importedRefId, ok := refIds[fmt.Sprint(refId)]
+		if !ok {
+			return fmt.Errorf("stage '%v' requires an unknown stage refId: %v", (*stage)["name"], refId)
+		}
+		importedRefIds = append(importedRefIds, importedRefId)
+	}
+	(*stage)["requisiteStageRefIds"] = importedRefIds
+	return nil
+}
+
+func (ps *Processor) copyStages() ([]map[string]interface{}, error) {
+	allStages := make([]map[string]interface{}, 0)
+	stagesJSON, err := json.Marshal(ps.Manifest.Spec.Stages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	err = json.Unmarshal(stagesJSON, &allStages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	return allStages, nil
+}
@@ -0,0 +1,136 @@
+package pipeline
+
+import (
+	"github.com/go-test/deep"
+	"strings"
+	"swinch/domain/datastore"
+	_ "swinch/testing"
+	"testing"
+)
+
+type importTest struct {
+	name     string
+	pipeJSON string
+	control  string
+}
+
+var pipelineImport = importTest{
+	"test_import",
+	"test/import/pipeline.json",
+	"test/manifests/test_import/pipeline.yaml",
+}
+
+func TestImportJSON(t *testing.T) {
+	i := importTest{}
+	i.runImportTest(pipelineImport, t)
+	i.runRoundTripTest(pipelineImport, t)
+}
+
+func (i importTest) runImportTest(test importTest, t *testing.T) {
+	t.Run(test.name, func(t *testing.T) {
+		p := Pipeline{}
+
+		control := readFile(t, test.control)
+		imported, err := p.ImportJSON(readFile(t, test.pipeJSON))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := deep.Equal(string(control), marshalYAML(t, imported)); diff != nil {
+			t.Error(diff)
+		}
+	})
+}
+
+// runRoundTripTest checks that importing a pipeline processed by swinch gives back the same manifest
+func (i importTest) runRoundTripTest(test importTest, t *testing.T) {
+	t.Run(test.name+"_round_trip", func(t *testing.T) {
+		d := datastore.Datastore{}
+		p := Pipeline{}
+		imported, err := p.ImportJSON(readFile(t, test.pipeJSON))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		processed := Pipeline{}
+		if _, err = processed.Load(imported); err != nil {
+			t.Fatal(err)
+		}
+		processedJSON, err := d.MarshalJSON(processed.Spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reimported := Pipeline{}
+		reimportedManifest, err := reimported.ImportJSON(processedJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := deep.Equal(marshalYAML(t, imported), marshalYAML(t, reimportedManifest)); diff != nil {
+			t.Error(diff)
+		}
+	})
+}
+
+func readFile(t *testing.T, filePath string) []byte {
+	d := datastore.Datastore{}
+	byteData, err := d.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return byteData
+}
+
+func marshalYAML(t *testing.T, data interface{}) string {
+	d := datastore.Datastore{}
+	byteData, err := d.MarshalYAML(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(byteData)
+}
+
+func TestImportJSONUnloadable(t *testing.T) {
+	pipeJSON := `{
+  "name": "Imported Pipeline",
+  "application": "test-import",
+  "stages": [
+    {"refId": "1", "requisiteStageRefIds": [], "name": "Wait", "type": "wait", "waitTime": 30},
+    {"refId": "2", "requisiteStageRefIds": ["1"], "name": "Check", "type": "wait", "waitTime": 30, "stageEnabled": {"type": "expression", "expresion": "true"}}
+  ]
+}`
+	p := Pipeline{}
+	_, err := p.ImportJSON([]byte(pipeJSON))
+	if err == nil || !strings.Contains(err.Error(), "stage 'Check'") {
+		t.Errorf("expected the import to fail on the stage swinch can't load, got %v", err)
+	}
+}
+
+func TestImportDuplicateStageNames(t *testing.T) {
+	pipeJSON := `{
+  "name": "Imported Pipeline",
+  "application": "test-import",
+  "stages": [
+    {"refId": "1", "requisiteStageRefIds": [], "name": "Wait", "type": "wait", "waitTime": 30},
+    {"refId": "2", "requisiteStageRefIds": [], "name": "Wait", "type": "wait", "waitTime": 30},
+    {"refId": "3", "requisiteStageRefIds": ["1"], "name": "Notify", "type": "wait", "waitTime": 30},
+    {"refId": "4", "requisiteStageRefIds": ["3"], "name": "Cleanup", "type": "wait", "waitTime": 30}
+  ]
+}`
+	p := Pipeline{}
+	imported, err := p.ImportJSON([]byte(pipeJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	references := make([]interface{}, 0)
+	for _, stage := range imported.Spec.Stages[2:] {
+		references = append(references, []interface{}{stage["requisiteStageRefIds"], stage["dependsOn"]})
+	}
+	// the stages depending on a duplicated name keep their refIds
+	expected := []interface{}{
+		[]interface{}{[]interface{}{"1"}, nil},
+		[]interface{}{nil, []interface{}{"Notify"}},
+	}
+	if diff := deep.Equal(references, expected); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -15,9 +15,10 @@ package pipeline
 import (
 	"encoding/json"
 	"errors"
-	log "github.com/sirupsen/logrus"
+	"fmt"
 	"gopkg.in/yaml.v3"
 	"swinch/domain/datastore"
+	"swinch/domain/owner"
 )
 
 const (
@@ -39,6 +40,8 @@ type Manifest struct {
 type Metadata struct {
 	Name        string `yaml:"name" json:"name"`
 	Application string `yaml:"application" json:"application"`
+	// Owner is set on the manifests rendered from a chart
+	Owner *owner.Owner `yaml:"owner,omitempty" json:"owner,omitempty"`
 }
 
 type Spec struct {
@@ -53,35 +56,49 @@ type Spec struct {
 	SpelEvaluator        string                   `yaml:"spelEvaluator,omitempty" json:"spelEvaluator,omitempty"`
 	Stages               []map[string]interface{} `yaml:"stages" json:"stages"`
 	Triggers             []interface{}            `yaml:"triggers,omitempty" json:"triggers,omitempty"`
+	// ManagedBy marks the pipelines saved by swinch, copied from the manifest owner
+	ManagedBy *owner.Owner `yaml:"-" json:"managedBy,omitempty"`
 }
 
 func (p *Pipeline) GetKind() string {
 	return Kind
 }
 
-func (p *Pipeline) Load(manifest interface{}) *Pipeline {
-	p.decode(manifest)
+func (p *Pipeline) Load(manifest interface{}) (*Pipeline, error) {
+	err := p.decode(manifest)
+	if err != nil {
+		return nil, err
+	}
 	p.inferFromMetadata()
-	p.processManifest(&p.Manifest)
+	err = p.processManifest(&p.Manifest, p.StageOptions)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline '%v': %w", p.Metadata.Name, err)
+	}
 
-	err := p.validate()
+	err = p.validate()
 	if err != nil {
-		log.Fatalf("Pipeline manifest validation failed: %v", err)
+		return nil, fmt.Errorf("pipeline manifest validation failed: %w", err)
 	}
-	return p
+	return p, nil
 }
 
-func (p *Pipeline) decode(manifest interface{}) {
+func (p *Pipeline) decode(manifest interface{}) error {
 	d := datastore.Datastore{}
-	err := yaml.Unmarshal(d.MarshalYAML(manifest), &p.Manifest)
+	manifestYAML, err := d.MarshalYAML(manifest)
 	if err != nil {
-		log.Fatalf("Error Load: %v", err)
+		return err
 	}
+	err = yaml.Unmarshal(manifestYAML, &p.Manifest)
+	if err != nil {
+		return fmt.Errorf("error loading pipeline manifest: %w", err)
+	}
+	return nil
 }
 
 func (p *Pipeline) inferFromMetadata() {
 	p.Manifest.Spec.Name = p.Manifest.Metadata.Name
 	p.Manifest.Spec.Application = p.Manifest.Metadata.Application
+	p.Manifest.Spec.ManagedBy = p.Manifest.Metadata.Owner
 }
 
 func (p *Pipeline) validate() error {
@@ -91,12 +108,12 @@ func (p *Pipeline) validate() error {
 	return nil
 }
 
-func (p *Pipeline) loadSpec(spec []byte) Spec {
+func (p *Pipeline) loadSpec(spec []byte) (Spec, error) {
 	tmpSpec := new(Spec)
 	err := json.Unmarshal(spec, tmpSpec)
 
 	if err != nil {
-		log.Fatalf("Error loading spec: %v", err)
+		return Spec{}, fmt.Errorf("error loading spec: %w", err)
 	}
-	return *tmpSpec
+	return *tmpSpec, nil
 }
@@ -0,0 +1,264 @@
+package pipeline
+
+import (
+	"github.com/go-test/deep"
+	"gopkg.in/yaml.v3"
+	"strings"
+	"swinch/domain/stages"
+	_ "swinch/testing"
+	"testing"
+)
+
+func TestLoadInvalidStages(t *testing.T) {
+	tests := map[string][]map[string]interface{}{
+		"unknown_type": {
+			{"name": "Unknown", "type": "unknownStage"},
+		},
+		"bake_without_artifacts": {
+			{"name": "Bake", "type": "bakeManifest"},
+		},
+		"deploy_without_bake": {
+			{"name": "Deploy", "type": "deployManifest", "requisiteStageRefIds": []interface{}{}},
+		},
+		"deploy_unknown_bake": {
+			{"name": "Deploy", "type": "deployManifest", "bakeStageRefIds": 5},
+		},
+		"unknown_key": {
+			{"name": "Wait", "type": "wait", "waitTime": 30, "waitTimme": 60},
+		},
+		"depends_on_unknown_stage": {
+			{"name": "Wait", "type": "wait", "dependsOn": []interface{}{"Bake"}},
+		},
+		"depends_on_ambiguous_stage": {
+			{"name": "Wait", "type": "wait"},
+			{"name": "Wait", "type": "wait"},
+			{"name": "Notify", "type": "wait", "dependsOn": []interface{}{"Wait"}},
+		},
+		"depends_on_and_requisite_stage_ref_ids": {
+			{"name": "Wait", "type": "wait"},
+			{"name": "Notify", "type": "wait", "dependsOn": []interface{}{"Wait"}, "requisiteStageRefIds": []interface{}{"1"}},
+		},
+		"depends_on_number": {
+			{"name": "Wait", "type": "wait"},
+			{"name": "Notify", "type": "wait", "dependsOn": 1},
+		},
+		"depends_on_itself": {
+			{"name": "Wait", "type": "wait", "dependsOn": []interface{}{"Wait"}},
+		},
+		"dependency_cycle": {
+			{"name": "Wait", "type": "wait", "dependsOn": []interface{}{"Notify"}},
+			{"name": "Check", "type": "wait", "dependsOn": []interface{}{"Wait"}},
+			{"name": "Notify", "type": "wait", "requisiteStageRefIds": []interface{}{"2"}},
+		},
+		"bake_stage_unknown": {
+			{"name": "Deploy", "type": "deployManifest", "requisiteStageRefIds": []interface{}{}, "bakeStage": "Bake"},
+		},
+		"bake_stage_and_bake_stage_ref_ids": {
+			{"name": "Bake", "type": "bakeManifest"},
+			{"name": "Deploy", "type": "deployManifest", "bakeStage": "Bake", "bakeStageRefIds": 1},
+		},
+		"unknown_nested_key": {
+			{"name": "Wait", "type": "wait", "waitTime": 30, "stageEnabled": map[string]interface{}{"expresion": "true"}},
+		},
+	}
+
+	for name, stages := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := Pipeline{}
+			_, err := p.Load(Manifest{
+				ApiVersion: API,
+				Kind:       Kind,
+				Metadata:   Metadata{Name: "test-pipeline", Application: "test"},
+				Spec:       Spec{Stages: stages},
+			})
+			if err == nil {
+				t.Error("expected the stage processing to fail")
+			}
+		})
+	}
+}
+
+func TestLoadUnknownKeys(t *testing.T) {
+	load := func(stage map[string]interface{}) (map[string]interface{}, error) {
+		p := Pipeline{}
+		_, err := p.Load(Manifest{
+			ApiVersion: API,
+			Kind:       Kind,
+			Metadata:   Metadata{Name: "test-pipeline", Application: "test"},
+			Spec:       Spec{Stages: []map[string]interface{}{stage}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return p.Spec.Stages[0], nil
+	}
+
+	_, err := load(map[string]interface{}{"name": "Wait", "type": "wait", "waitTimme": 60})
+	expected := "pipeline 'test-pipeline': stage 'Wait' of type wait: unknown key 'waitTimme', did you mean 'waitTime'?, set 'strict: false' on the stage to pass unknown keys through"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected the unknown key error, got %v", err)
+	}
+
+	stage, err := load(map[string]interface{}{"name": "Wait", "type": "wait", "strict": false, "trafficManagement": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stage["trafficManagement"] != true {
+		t.Error("expected the unknown key passed through")
+	}
+	if _, ok := stage["strict"]; ok {
+		t.Error("expected the strict key to be swinch only")
+	}
+
+	_, err = load(map[string]interface{}{"name": "Wait", "type": "wait", "strict": false, "stageEnabled": map[string]interface{}{"expresion": "true"}})
+	if err == nil || strings.Contains(err.Error(), "strict: false") {
+		t.Errorf("expected the nested unknown key to fail without the strict hint, got %v", err)
+	}
+
+	parameters := map[string]interface{}{"LdapEditGroup": "editors", "Replicas": 3}
+	stage, err = load(map[string]interface{}{"name": "Build", "type": "jenkins", "master": "ci", "job": "build", "parameters": parameters})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(stage["parameters"], map[string]interface{}{"LdapEditGroup": "editors", "Replicas": float64(3)}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestLoadPassThroughStages(t *testing.T) {
+	webhook := map[string]interface{}{
+		"name":         "Webhook",
+		"type":         "webhook",
+		"ifStageFails": stages.IgnoreStageFailure,
+		"url":          "https://example.com/hook",
+		"payload":      map[string]interface{}{"application": "test"},
+	}
+	load := func(stage map[string]interface{}, options stages.ProcessOptions) (map[string]interface{}, error) {
+		p := Pipeline{StageOptions: options}
+		_, err := p.Load(Manifest{
+			ApiVersion: API,
+			Kind:       Kind,
+			Metadata:   Metadata{Name: "test-pipeline", Application: "test"},
+			Spec:       Spec{Stages: []map[string]interface{}{stage}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return p.Spec.Stages[0], nil
+	}
+
+	if _, err := load(webhook, stages.ProcessOptions{}); err == nil {
+		t.Error("expected the stage type not modeled by swinch to fail by default")
+	}
+	allowlist := stages.ProcessOptions{PassThrough: stages.PassThroughConfig{Enabled: true, Allowlist: []string{"checkPreconditions"}}}
+	if _, err := load(webhook, allowlist); err == nil {
+		t.Error("expected the stage type missing from the allowlist to fail")
+	}
+
+	stage, err := load(webhook, stages.ProcessOptions{PassThrough: stages.PassThroughConfig{Enabled: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"name":                          "Webhook",
+		"type":                          "webhook",
+		"refId":                         "1",
+		"requisiteStageRefIds":          nil,
+		"continuePipeline":              true,
+		"failPipeline":                  false,
+		"completeOtherBranchesThenFail": false,
+		"url":                           "https://example.com/hook",
+		"payload":                       map[string]interface{}{"application": "test"},
+	}
+	if diff := deep.Equal(stage, expected); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestLoadDependsOn(t *testing.T) {
+	p := Pipeline{}
+	_, err := p.Load(Manifest{
+		ApiVersion: API,
+		Kind:       Kind,
+		Metadata:   Metadata{Name: "test-pipeline", Application: "test"},
+		Spec: Spec{Stages: []map[string]interface{}{
+			{"name": "Wait staging", "type": "wait", "requisiteStageRefIds": []interface{}{}},
+			{"name": "Wait prod", "type": "wait", "dependsOn": []interface{}{"Wait staging"}},
+			{"name": "Notify", "type": "wait", "dependsOn": []interface{}{"Wait prod", "Wait staging"}},
+			{"name": "Cleanup", "type": "wait", "requisiteStageRefIds": []interface{}{"3"}},
+			{"name": "Check", "type": "wait", "dependsOn": "Cleanup"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refIds := make([]interface{}, 0)
+	for _, stage := range p.Spec.Stages {
+		refIds = append(refIds, stage["requisiteStageRefIds"])
+		if _, ok := stage["dependsOn"]; ok {
+			t.Errorf("expected dependsOn to be swinch only, got it on stage '%v'", stage["name"])
+		}
+	}
+	expected := []interface{}{[]interface{}{}, []interface{}{"1"}, []interface{}{"2", "1"}, []interface{}{"3"}, []interface{}{"4"}}
+	if diff := deep.Equal(refIds, expected); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestLoadLenientStages(t *testing.T) {
+	p := Pipeline{StageOptions: stages.ProcessOptions{Lenient: true}}
+	_, err := p.Load(Manifest{
+		ApiVersion: API,
+		Kind:       Kind,
+		Metadata:   Metadata{Name: "test-pipeline", Application: "test"},
+		Spec: Spec{Stages: []map[string]interface{}{
+			{"name": "Wait", "type": "wait", "trafficManagement": true},
+			{"name": "Strict wait", "type": "wait", "strict": true, "trafficManagement": true},
+		}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "Strict wait") {
+		t.Errorf("expected the strict stage to fail, got %v", err)
+	}
+}
+
+func TestLoadStageCycle(t *testing.T) {
+	p := Pipeline{}
+	_, err := p.Load(Manifest{
+		ApiVersion: API,
+		Kind:       Kind,
+		Metadata:   Metadata{Name: "test-pipeline", Application: "test"},
+		Spec: Spec{Stages: []map[string]interface{}{
+			{"name": "Wait", "type": "wait", "dependsOn": []interface{}{"Notify"}},
+			{"name": "Check", "type": "wait", "dependsOn": []interface{}{"Wait"}},
+			{"name": "Notify", "type": "wait", "dependsOn": []interface{}{"Check"}},
+		}},
+	})
+	expected := "pipeline 'test-pipeline': stages 'Wait' -> 'Notify' -> 'Check' -> 'Wait' depend on each other in a cycle"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected the cycle error, got %v", err)
+	}
+}
+
+func TestLoadBakeStageName(t *testing.T) {
+	// the second deploy stage requires the second bake, it is bound to the first bake explicitly
+	load := func(bind func(deploy map[string]interface{})) map[string]interface{} {
+		m := Manifest{}
+		if err := yaml.Unmarshal(readFile(t, "test/manifests/test_template_simple/pipeline.yaml"), &m); err != nil {
+			t.Fatal(err)
+		}
+		bind(m.Spec.Stages[3])
+		p := Pipeline{}
+		if _, err := p.Load(m); err != nil {
+			t.Fatal(err)
+		}
+		return p.Spec.Stages[3]
+	}
+
+	byRefId := load(func(deploy map[string]interface{}) { deploy["bakeStageRefIds"] = 1 })
+	byName := load(func(deploy map[string]interface{}) { deploy["bakeStage"] = "Bake Cluster 1 - test-ns-1" })
+	byRequisite := load(func(deploy map[string]interface{}) {})
+	if byName["manifestArtifactId"] != byRefId["manifestArtifactId"] || byName["manifestArtifactId"] == byRequisite["manifestArtifactId"] {
+		t.Errorf("expected the deploy bound to the first bake, got artifact %v", byName["manifestArtifactId"])
+	}
+}
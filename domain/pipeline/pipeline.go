@@ -13,8 +13,13 @@ governing permissions and limitations under the License.
 package pipeline
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	log "github.com/sirupsen/logrus"
+	"swinch/domain/change"
 	"swinch/domain/datastore"
+	"swinch/domain/stages"
 	"swinch/domain/util"
 	"swinch/spincli"
 )
@@ -23,38 +28,101 @@ type Pipeline struct {
 	Manifest
 	Processor
 	util.Util
-	spincli.PipelineAPI
 	datastore.Datastore
+	// Backend stores the pipelines, Spinnaker Gate is used if none is set
+	Backend spincli.PipelineBackend
+	// StageOptions configure how the stages are processed on load and checked on import
+	StageOptions stages.ProcessOptions
 }
 
-func (p *Pipeline) Plan() {
-	p.Apply(true, true)
+func (p *Pipeline) backend() spincli.PipelineBackend {
+	if p.Backend == nil {
+		p.Backend = &spincli.PipelineAPI{}
+	}
+	return p.Backend
 }
 
-func (p *Pipeline) Apply(dryRun, plan bool) {
-	existingPipe := p.Get(p.Metadata.Application, p.Metadata.Name)
-	changes := false
-	newPipe := false
-	if len(existingPipe) == 0 {
-		newPipe = true
-	} else {
-		changes = p.Changes(p.MarshalJSON(p.loadSpec(existingPipe)), p.MarshalJSON(p.Spec))
-		if changes == false {
-			log.Infof("No changes detected for pipeline '%v' in application '%v'", p.Metadata.Name, p.Metadata.Application)
-		}
+func (p *Pipeline) Plan(ctx context.Context) error {
+	return p.Apply(ctx, true, true)
+}
+
+func (p *Pipeline) Apply(ctx context.Context, dryRun, plan bool) error {
+	c, err := p.Change(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.Action == change.NoOp {
+		log.Infof("No changes detected for pipeline '%v' in application '%v'", p.Metadata.Name, p.Metadata.Application)
 	}
 
-	if changes && plan {
+	if c.Action == change.Update && plan {
 		log.Infof("Planing changes for pipeline '%v' in application '%v'", p.Metadata.Name, p.Metadata.Application)
-		p.DiffChanges(p.MarshalJSON(p.loadSpec(existingPipe)), p.MarshalJSON(p.Spec))
+		log.Info(c.FieldsDiff())
+	}
+
+	if !dryRun && c.Action != change.NoOp {
+		return p.Save(ctx)
+	}
+	return nil
+}
+
+// Change compares the pipeline in Spinnaker with the manifest
+func (p *Pipeline) Change(ctx context.Context) (change.Change, error) {
+	existingPipe, err := p.backend().Get(ctx, p.Metadata.Application, p.Metadata.Name)
+	if err != nil {
+		return change.Change{}, err
+	}
+	var existingJSON []byte
+	if len(existingPipe) != 0 {
+		existingSpec, err := p.loadSpec(existingPipe)
+		if err != nil {
+			return change.Change{}, err
+		}
+		if existingJSON, err = p.MarshalJSON(existingSpec); err != nil {
+			return change.Change{}, err
+		}
+	}
+	newJSON, err := p.MarshalJSON(p.Spec)
+	if err != nil {
+		return change.Change{}, err
 	}
+	return change.New(Kind, p.Metadata.Application, p.Metadata.Name, existingJSON, newJSON)
+}
+
+// Save creates or updates the pipeline in Spinnaker
+func (p *Pipeline) Save(ctx context.Context) error {
+	log.Infof("Saving pipeline '%v' in application '%v'", p.Metadata.Name, p.Metadata.Application)
+	return p.backend().Save(ctx, p.Metadata.Application, p.Metadata.Name, p.Spec)
+}
 
-	if !dryRun && (changes || newPipe) {
-		log.Infof("Saving pipeline '%v' in application '%v'", p.Metadata.Name, p.Metadata.Application)
-		p.Save(p.Metadata.Application, p.Metadata.Name, p.WriteJSONTmp(p.Spec))
+// Snapshot returns the pipeline saved in Spinnaker as is, empty if the pipeline is missing, to be restored with Restore
+func (p *Pipeline) Snapshot(ctx context.Context) ([]byte, error) {
+	return p.backend().Get(ctx, p.Metadata.Application, p.Metadata.Name)
+}
+
+// Restore saves back a pipeline snapshot, an empty snapshot deletes the pipeline
+func (p *Pipeline) Restore(ctx context.Context, snapshot []byte) error {
+	if len(snapshot) == 0 {
+		return p.backend().Delete(ctx, p.Metadata.Application, p.Metadata.Name)
 	}
+	return p.backend().Save(ctx, p.Metadata.Application, p.Metadata.Name, json.RawMessage(snapshot))
 }
 
-func (p *Pipeline) Destroy() {
-	p.Delete(p.Metadata.Application, p.Metadata.Name)
+// Destroy deletes the pipeline, a manifest rendered from a chart only deletes a pipeline owned by the same chart
+func (p *Pipeline) Destroy(ctx context.Context) error {
+	if p.Metadata.Owner != nil {
+		existingPipe, err := p.backend().Get(ctx, p.Metadata.Application, p.Metadata.Name)
+		if err != nil || len(existingPipe) == 0 {
+			return err
+		}
+		existingSpec, err := p.loadSpec(existingPipe)
+		if err != nil {
+			return err
+		}
+		if !existingSpec.ManagedBy.OwnedBy(p.Metadata.Owner.Chart) {
+			return fmt.Errorf("pipeline '%v' in application '%v' is not owned by chart '%v'", p.Metadata.Name, p.Metadata.Application, p.Metadata.Owner.Chart)
+		}
+	}
+	return p.backend().Delete(ctx, p.Metadata.Application, p.Metadata.Name)
 }
@@ -0,0 +1,203 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-test/deep"
+	"gopkg.in/yaml.v3"
+	"swinch/spincli"
+	"swinch/spincli/gatetest"
+	_ "swinch/testing"
+	"testing"
+)
+
+type backendTest struct {
+	name         string
+	applications spincli.ApplicationBackend
+	pipelines    spincli.PipelineBackend
+}
+
+func backends(t *testing.T) []backendTest {
+	memory := spincli.NewMemory()
+	gate := gatetest.NewServer()
+	t.Cleanup(gate.Close)
+	client := spincli.Gate{Client: gate.Client()}
+
+	return []backendTest{
+		{"memory", memory.Applications(), memory.Pipelines()},
+		{"gate", &spincli.ApplicationAPI{Gate: client}, &spincli.PipelineAPI{Gate: client}},
+	}
+}
+
+func TestApplyPipeline(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			manifest := Manifest{}
+			if err := yaml.Unmarshal(readFile(t, pipelineImport.control), &manifest); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.applications.Save(context.Background(), manifest.Metadata.Application, map[string]interface{}{"name": manifest.Metadata.Application}); err != nil {
+				t.Fatal(err)
+			}
+
+			p := Pipeline{Backend: b.pipelines}
+			if _, err := p.Load(manifest); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := p.Plan(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if existingPipe := getPipeline(t, b.pipelines, p.Metadata); len(existingPipe) != 0 {
+				t.Fatalf("plan saved the pipeline: %s", existingPipe)
+			}
+
+			if err := p.Apply(context.Background(), false, false); err != nil {
+				t.Fatal(err)
+			}
+			if diff := deep.Equal(loadSpec(t, getPipeline(t, b.pipelines, p.Metadata)), p.Spec); diff != nil {
+				t.Error(diff)
+			}
+
+			p.Spec.Disabled = true
+			if err := p.Apply(context.Background(), false, true); err != nil {
+				t.Fatal(err)
+			}
+			if diff := deep.Equal(loadSpec(t, getPipeline(t, b.pipelines, p.Metadata)), p.Spec); diff != nil {
+				t.Error(diff)
+			}
+
+			if err := p.Destroy(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if existingPipe := getPipeline(t, b.pipelines, p.Metadata); len(existingPipe) != 0 {
+				t.Errorf("pipeline not deleted: %s", existingPipe)
+			}
+		})
+	}
+}
+
+func TestApplyPipelineMissingApplication(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			p := Pipeline{Backend: b.pipelines}
+			if _, err := p.Load(Manifest{ApiVersion: API, Kind: Kind, Metadata: Metadata{Name: "test-pipeline", Application: "missing"}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Apply(context.Background(), false, false); err == nil {
+				t.Error("expected saving a pipeline in a missing application to fail")
+			}
+		})
+	}
+}
+
+func TestApplyPipelineKeepsId(t *testing.T) {
+	gate := gatetest.NewServer()
+	defer gate.Close()
+	client := spincli.Gate{Client: gate.Client()}
+	a := spincli.ApplicationAPI{Gate: client}
+	if err := a.Save(context.Background(), "test", map[string]interface{}{"name": "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := Pipeline{Backend: &spincli.PipelineAPI{Gate: client}}
+	if _, err := p.Load(Manifest{ApiVersion: API, Kind: Kind, Metadata: Metadata{Name: "test-pipeline", Application: "test"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Apply(context.Background(), false, false); err != nil {
+		t.Fatal(err)
+	}
+	id := gate.Pipeline("test", "test-pipeline")["id"]
+
+	p.Spec.LimitConcurrent = true
+	if err := p.Apply(context.Background(), false, false); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(gate.Pipeline("test", "test-pipeline")["id"], id); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestImportFromBackend(t *testing.T) {
+	memory := spincli.NewMemory()
+	spec := make(map[string]interface{})
+	if err := json.Unmarshal(readFile(t, pipelineImport.pipeJSON), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if err := memory.Applications().Save(context.Background(), "test-import", map[string]interface{}{"name": "test-import"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := memory.Pipelines().Save(context.Background(), "test-import", "Imported Pipeline", spec); err != nil {
+		t.Fatal(err)
+	}
+
+	p := Pipeline{Backend: memory.Pipelines()}
+	manifests, err := p.ImportAll(context.Background(), "test-import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 1 {
+		t.Fatalf("expected 1 imported pipeline, got %d", len(manifests))
+	}
+	if diff := deep.Equal(marshalYAML(t, manifests[0]), string(readFile(t, pipelineImport.control))); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func getPipeline(t *testing.T, backend spincli.PipelineBackend, metadata Metadata) []byte {
+	pipe, err := backend.Get(context.Background(), metadata.Application, metadata.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pipe
+}
+
+func loadSpec(t *testing.T, pipe []byte) Spec {
+	p := Pipeline{}
+	spec, err := p.loadSpec(pipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			if err := b.applications.Save(context.Background(), "test", map[string]interface{}{"name": "test"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.pipelines.Save(context.Background(), "test", "existing", Spec{Application: "test", Name: "existing", SpelEvaluator: "v4"}); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range []string{"existing", "new"} {
+				p := Pipeline{Backend: b.pipelines}
+				p.Metadata = Metadata{Name: name, Application: "test"}
+				p.Spec = Spec{Application: "test", Name: name, SpelEvaluator: "v3"}
+
+				snapshot, err := p.Snapshot(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = p.Save(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				if err = p.Restore(context.Background(), snapshot); err != nil {
+					t.Fatal(err)
+				}
+
+				restored := getPipeline(t, b.pipelines, p.Metadata)
+				if len(snapshot) == 0 {
+					if len(restored) != 0 {
+						t.Errorf("expected the new pipeline '%v' to be deleted, got: %s", name, restored)
+					}
+					continue
+				}
+				if diff := deep.Equal(loadSpec(t, restored), loadSpec(t, snapshot)); diff != nil {
+					t.Error(diff)
+				}
+			}
+		})
+	}
+}
@@ -13,21 +13,37 @@ governing permissions and limitations under the License.
 package pipeline
 
 import (
-	log "github.com/sirupsen/logrus"
+	"fmt"
 	"strconv"
+	"strings"
 	"swinch/domain/stages"
 )
 
 type Processor struct {
 	Manifest
 	stages.Stages
+	Options stages.ProcessOptions
 }
 
-func (ps *Processor) processManifest(manifest *Manifest) {
+func (ps *Processor) processManifest(manifest *Manifest, options stages.ProcessOptions) error {
 	ps.Stages.GetTypes()
+	ps.Options = options
 	ps.Manifest = *manifest
+	// Stages referenced by name are resolved first, the bake stages are looked up by refId
+	for _, stage := range ps.Manifest.Spec.Stages {
+		if err := ResolveStageNames(stage, ps.Manifest.Spec.Stages); err != nil {
+			return err
+		}
+	}
+	if err := CheckCycles(ps.Manifest.Spec.Stages); err != nil {
+		return err
+	}
 	for i := 0; i < len(ps.Manifest.Spec.Stages); i++ {
-		ps.Stage = ps.Decode(&ps.Manifest.Spec.Stages[i])
+		stage, err := ps.Decode(&ps.Manifest.Spec.Stages[i])
+		if err != nil {
+			return err
+		}
+		ps.Stage = stage
 		ps.InitStage = &ps.Manifest.Spec.Stages[i]
 		ps.AllStages = &ps.Manifest.Spec.Stages
 
@@ -37,15 +53,64 @@ func (ps *Processor) processManifest(manifest *Manifest) {
 		ps.Stage.ManifestMetadata.Name = ps.Manifest.Metadata.Name
 		ps.Stage.ManifestMetadata.Application = ps.Manifest.Metadata.Application
 
-		stageType := stages.StageType(ps.Stage.Type)
-		_, ok := ps.Types[stageType]
+		stageType, ok := ps.GetType(stages.StageType(ps.Stage.Type), ps.Options.PassThrough)
 		if !ok {
-			log.Fatalf("Failed to detect stage type: %v", ps.Stage.Type)
+			return fmt.Errorf("stage '%v': failed to detect stage type: %v", ps.Stage.Metadata.Name, ps.Stage.Type)
+		}
+
+		// Typos would be dropped silently, unknown keys fail the stage or are passed through if the stage is not strict
+		passThrough, err := ps.unknownKeys(stageType)
+		if err != nil {
+			return err
 		}
 
 		ps.FailStageSetter()
 
 		//Overwrite the initial stage map with he newly generated stage spec
-		*ps.InitStage = *ps.Types[stageType].MakeStage(&ps.Stage)
+		newStage, err := stageType.MakeStage(&ps.Stage)
+		if err != nil {
+			return fmt.Errorf("stage '%v': %w", ps.Stage.Metadata.Name, err)
+		}
+		for key, value := range passThrough {
+			if _, ok := (*newStage)[key]; !ok {
+				(*newStage)[key] = value
+			}
+		}
+		*ps.InitStage = *newStage
+	}
+	return nil
+}
+
+// unknownKeys fails a strict stage with unknown keys, for a stage with strict false the unknown top level keys are returned to be passed through
+// nested unknown keys can't be passed through, they always fail the stage instead of being dropped
+func (ps *Processor) unknownKeys(stageType stages.S) (map[string]interface{}, error) {
+	unknown := stages.UnknownKeys(stageType, *ps.InitStage)
+	if len(unknown) == 0 {
+		return nil, nil
+	}
+	strict := !ps.Options.Lenient
+	if ps.Stage.Strict != nil {
+		strict = *ps.Stage.Strict
+	}
+
+	passThrough := make(map[string]interface{})
+	messages := make([]string, 0)
+	nested := false
+	for _, key := range unknown {
+		value, topLevel := (*ps.InitStage)[key.Path]
+		if topLevel && !strict {
+			passThrough[key.Path] = value
+			continue
+		}
+		nested = nested || !topLevel
+		messages = append(messages, key.String())
+	}
+	switch {
+	case len(messages) == 0:
+		return passThrough, nil
+	case nested:
+		return nil, fmt.Errorf("stage '%v' of type %v: %v, nested keys can't be passed through", ps.Stage.Metadata.Name, ps.Stage.Type, strings.Join(messages, ", "))
+	default:
+		return nil, fmt.Errorf("stage '%v' of type %v: %v, set 'strict: false' on the stage to pass unknown keys through", ps.Stage.Metadata.Name, ps.Stage.Type, strings.Join(messages, ", "))
 	}
 }
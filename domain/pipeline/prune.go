@@ -0,0 +1,88 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"swinch/domain/change"
+)
+
+// List returns the specs of all the application pipelines
+func (p *Pipeline) List(ctx context.Context, appName string) ([]Spec, error) {
+	pipesJSON, err := p.backend().List(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	pipes := make([]json.RawMessage, 0)
+	if len(pipesJSON) != 0 {
+		if err = json.Unmarshal(pipesJSON, &pipes); err != nil {
+			return nil, fmt.Errorf("error loading pipelines of application '%v': %w", appName, err)
+		}
+	}
+
+	specs := make([]Spec, 0)
+	for _, pipeJSON := range pipes {
+		spec, err := p.loadSpec(pipeJSON)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// Unmanaged returns the deletion of the application pipelines owned by one of the charts and missing from the managed pipelines
+// the pipelines matching an allowlist pattern, like "manual-*", are kept even if owned by a chart
+func (p *Pipeline) Unmanaged(ctx context.Context, appName string, managed map[string]bool, charts map[string]bool, allowlist []string) ([]change.Change, error) {
+	for _, pattern := range allowlist {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad allowlist pattern '%v': %w", pattern, err)
+		}
+	}
+
+	specs, err := p.List(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]change.Change, 0)
+	for _, spec := range specs {
+		if managed[spec.Name] || allowlisted(spec.Name, allowlist) {
+			continue
+		}
+		if spec.ManagedBy == nil || !charts[spec.ManagedBy.Chart] {
+			continue
+		}
+		existingJSON, err := p.MarshalJSON(spec)
+		if err != nil {
+			return nil, err
+		}
+		c, err := change.New(Kind, appName, spec.Name, existingJSON, nil)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+func allowlisted(pipeName string, allowlist []string) bool {
+	for _, pattern := range allowlist {
+		if ok, _ := filepath.Match(pattern, pipeName); ok {
+			return true
+		}
+	}
+	return false
+}
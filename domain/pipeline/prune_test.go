@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"context"
+	"github.com/go-test/deep"
+	"swinch/domain/change"
+	"swinch/domain/owner"
+	"testing"
+)
+
+func TestUnmanaged(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			if err := b.applications.Save(context.Background(), "test", map[string]interface{}{"name": "test"}); err != nil {
+				t.Fatal(err)
+			}
+			pipes := map[string]string{"deploy": "chart", "removed": "chart", "manual-rollback": "chart", "other-chart": "other", "unowned": ""}
+			for name, chart := range pipes {
+				spec := Spec{Application: "test", Name: name}
+				if chart != "" {
+					spec.ManagedBy = &owner.Owner{Chart: chart}
+				}
+				if err := b.pipelines.Save(context.Background(), "test", name, spec); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			p := Pipeline{Backend: b.pipelines}
+			changes, err := p.Unmanaged(context.Background(), "test", map[string]bool{"deploy": true}, map[string]bool{"chart": true}, []string{"manual-*"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := make([]string, 0)
+			for _, c := range changes {
+				if c.Action != change.Delete {
+					t.Errorf("expected a delete action for pipeline '%v', got %v", c.Name, c.Action)
+				}
+				result = append(result, c.Name)
+			}
+			if diff := deep.Equal(result, []string{"removed"}); diff != nil {
+				t.Error(diff)
+			}
+
+			if _, err = p.Unmanaged(context.Background(), "test", nil, nil, []string{"["}); err == nil {
+				t.Error("expected a bad allowlist pattern error")
+			}
+		})
+	}
+}
+
+func TestDestroyOwned(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			if err := b.applications.Save(context.Background(), "test", map[string]interface{}{"name": "test"}); err != nil {
+				t.Fatal(err)
+			}
+			for name, chart := range map[string]string{"owned": "chart", "other-chart": "other"} {
+				spec := Spec{Application: "test", Name: name, ManagedBy: &owner.Owner{Chart: chart}}
+				if err := b.pipelines.Save(context.Background(), "test", name, spec); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for name, owned := range map[string]bool{"owned": true, "other-chart": false} {
+				p := Pipeline{Backend: b.pipelines}
+				p.Metadata = Metadata{Name: name, Application: "test", Owner: &owner.Owner{Chart: "chart"}}
+				err := p.Destroy(context.Background())
+				if owned && err != nil {
+					t.Errorf("expected pipeline '%v' to be deleted, got: %v", name, err)
+				}
+				if !owned && err == nil {
+					t.Errorf("expected pipeline '%v' owned by another chart to be kept", name)
+				}
+				if deleted := len(getPipeline(t, b.pipelines, p.Metadata)) == 0; deleted != owned {
+					t.Errorf("pipeline '%v' deleted: %v, expected: %v", name, deleted, owned)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// bakeReferences are the stage keys binding a deploy or run job stage to its bake stage by name, with the refId keys they are resolved to
+var bakeReferences = [][2]string{
+	{"bakeStage", "bakeStageRefIds"},
+	{"jobBakeStage", "jobBakeStageRefIds"},
+}
+
+// ResolveStageNames sets the refIds of the stages a stage references by name, dependsOn is resolved to requisiteStageRefIds
+// and bakeStage or jobBakeStage to the bake refIds; the stage refIds are the stage positions, as set by processManifest
+// the stages referenced by refId are left as they are
+func ResolveStageNames(stage map[string]interface{}, allStages []map[string]interface{}) error {
+	refIds := make(map[string][]string)
+	for i, s := range allStages {
+		name := fmt.Sprint(s["name"])
+		refIds[name] = append(refIds[name], strconv.Itoa(i+1))
+	}
+
+	if dependsOn, ok := stage["dependsOn"]; ok && dependsOn != nil {
+		if requisiteStageRefIds, _ := stage["requisiteStageRefIds"].([]interface{}); len(requisiteStageRefIds) > 0 {
+			return fmt.Errorf("stage '%v' sets both dependsOn and requisiteStageRefIds, use only one", stage["name"])
+		}
+		requisiteStageRefIds := make([]interface{}, 0)
+		for _, name := range stageNames(dependsOn) {
+			refId, err := resolveName(stage, name, refIds, "depends on")
+			if err != nil {
+				return err
+			}
+			requisiteStageRefIds = append(requisiteStageRefIds, refId)
+		}
+		stage["requisiteStageRefIds"] = requisiteStageRefIds
+	}
+
+	for _, reference := range bakeReferences {
+		nameKey, refIdKey := reference[0], reference[1]
+		name, ok := stage[nameKey]
+		if !ok || name == nil {
+			continue
+		}
+		if refId, ok := stage[refIdKey]; ok && refId != nil {
+			return fmt.Errorf("stage '%v' sets both %v and %v, use only one", stage["name"], nameKey, refIdKey)
+		}
+		refId, err := resolveName(stage, fmt.Sprint(name), refIds, "is bound to")
+		if err != nil {
+			return err
+		}
+		stage[refIdKey], _ = strconv.Atoi(refId)
+	}
+	return nil
+}
+
+// resolveName returns the refId of the stage with a name, the name must match a single stage other than the referencing stage
+func resolveName(stage map[string]interface{}, name string, refIds map[string][]string, relation string) (string, error) {
+	if name == fmt.Sprint(stage["name"]) {
+		return "", fmt.Errorf("stage '%v' %v itself", stage["name"], relation)
+	}
+	refId, ok := refIds[name]
+	switch {
+	case !ok:
+		return "", fmt.Errorf("stage '%v' %v unknown stage '%v'", stage["name"], relation, name)
+	case len(refId) > 1:
+		return "", fmt.Errorf("stage '%v' %v stage '%v' which is ambiguous, %d stages have this name", stage["name"], relation, name, len(refId))
+	}
+	return refId[0], nil
+}
+
+// CheckCycles returns an error naming the stages of the first requisiteStageRefIds cycle found, Spinnaker can't run such a pipeline
+func CheckCycles(allStages []map[string]interface{}) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(allStages))
+	path := make([]int, 0)
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		state[i] = visiting
+		path = append(path, i)
+		requisiteStageRefIds, _ := allStages[i]["requisiteStageRefIds"].([]interface{})
+		for _, refId := range requisiteStageRefIds {
+			index, err := strconv.Atoi(fmt.Sprint(refId))
+			if err != nil || index < 1 || index > len(allStages) {
+				continue
+			}
+			next := index - 1
+			switch state[next] {
+			case visiting:
+				return cycleError(allStages, path, next)
+			case unvisited:
+				if err := visit(next); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range allStages {
+		if state[i] == unvisited {
+			if err := visit(i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cycleError names the stages of the cycle, from the stage closing the cycle along the dependencies
+func cycleError(allStages []map[string]interface{}, path []int, start int) error {
+	names := make([]string, 0)
+	for j := len(path) - 1; j >= 0; j-- {
+		if path[j] == start {
+			for _, i := range path[j:] {
+				names = append(names, fmt.Sprintf("'%v'", allStages[i]["name"]))
+			}
+			break
+		}
+	}
+	names = append(names, fmt.Sprintf("'%v'", allStages[start]["name"]))
+	return fmt.Errorf("stages %v depend on each other in a cycle", strings.Join(names, " -> "))
+}
+
+// stageNames returns the stage names of a decoded YAML list, a single name is a list of one name
+func stageNames(data interface{}) []string {
+	switch names := data.(type) {
+	case []string:
+		return names
+	case []interface{}:
+		list := make([]string, 0)
+		for _, name := range names {
+			list = append(list, fmt.Sprint(name))
+		}
+		return list
+	default:
+		return []string{fmt.Sprint(names)}
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package release
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"swinch/domain/datastore"
+	"time"
+)
+
+const (
+	Deployed    = "deployed"
+	Failed      = "failed"
+	Superseded  = "superseded"
+	Uninstalled = "uninstalled"
+
+	revisionFilePrefix = "revision-"
+	revisionFileExt    = ".yaml"
+
+	// the revisions keep the rendered manifests and values, only the user can read the store
+	dirPerm  = 0700
+	filePerm = 0600
+)
+
+var releaseName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Revision is an install, upgrade or rollback of a release, with the applied manifests
+type Revision struct {
+	Release      string                      `yaml:"release" json:"release"`
+	Number       int                         `yaml:"revision" json:"revision"`
+	Updated      time.Time                   `yaml:"updated" json:"updated"`
+	Status       string                      `yaml:"status" json:"status"`
+	Description  string                      `yaml:"description" json:"description"`
+	Chart        string                      `yaml:"chart" json:"chart"`
+	ChartVersion string                      `yaml:"chartVersion" json:"chartVersion"`
+	Values       map[interface{}]interface{} `yaml:"values,omitempty" json:"-"`
+	Manifests    string                      `yaml:"manifests,omitempty" json:"-"`
+}
+
+// Store keeps the release revisions in a local state directory, a folder for each release and a file for each revision
+type Store struct {
+	Path string
+	datastore.Datastore
+}
+
+// ValidateName checks the release name is a lowercase dns label, it names the release state folder
+func ValidateName(name string) error {
+	if !releaseName.MatchString(name) {
+		return fmt.Errorf("invalid release name '%v', use lowercase letters, digits and '-'", name)
+	}
+	return nil
+}
+
+// Record saves the revision as the next revision of the release
+// a deployed or uninstalled revision supersedes the previous deployed revision
+func (s Store) Record(revision Revision) (Revision, error) {
+	if err := ValidateName(revision.Release); err != nil {
+		return Revision{}, err
+	}
+	history, err := s.History(revision.Release)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	revision.Number = 1
+	if len(history) > 0 {
+		revision.Number = history[len(history)-1].Number + 1
+	}
+	if revision.Updated.IsZero() {
+		revision.Updated = time.Now()
+	}
+
+	if revision.Status == Deployed || revision.Status == Uninstalled {
+		for _, previous := range history {
+			if previous.Status != Deployed {
+				continue
+			}
+			previous.Status = Superseded
+			if err = s.write(previous); err != nil {
+				return Revision{}, err
+			}
+		}
+	}
+	return revision, s.write(revision)
+}
+
+// History returns the release revisions, oldest first, an unknown release has no revisions
+func (s Store) History(name string) ([]Revision, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(s.releasePath(name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the history of release '%v': %w", name, err)
+	}
+
+	history := make([]Revision, 0)
+	for _, file := range files {
+		number, ok := revisionNumber(file.Name())
+		if file.IsDir() || !ok {
+			continue
+		}
+		revision, err := s.Get(name, number)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, revision)
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Number < history[j].Number })
+	return history, nil
+}
+
+// Get returns a revision of the release
+func (s Store) Get(name string, number int) (Revision, error) {
+	if err := ValidateName(name); err != nil {
+		return Revision{}, err
+	}
+	revisionPath := s.revisionPath(name, number)
+	if !s.FileExists(revisionPath) {
+		return Revision{}, fmt.Errorf("release '%v' has no revision %d", name, number)
+	}
+	byteData, err := s.ReadFile(revisionPath)
+	if err != nil {
+		return Revision{}, err
+	}
+	revision := Revision{}
+	if err = yaml.Unmarshal(byteData, &revision); err != nil {
+		return Revision{}, fmt.Errorf("failed to load revision %d of release '%v': %w", number, name, err)
+	}
+	return revision, nil
+}
+
+func (s Store) write(revision Revision) error {
+	err := s.Mkdir(s.releasePath(revision.Release), dirPerm)
+	if err != nil {
+		return err
+	}
+	byteData, err := s.MarshalYAML(revision)
+	if err != nil {
+		return err
+	}
+	return s.WriteFile(s.revisionPath(revision.Release, revision.Number), byteData, filePerm)
+}
+
+func (s Store) releasePath(name string) string {
+	return filepath.Join(s.Path, name)
+}
+
+func (s Store) revisionPath(name string, number int) string {
+	return filepath.Join(s.releasePath(name), fmt.Sprintf("%v%d%v", revisionFilePrefix, number, revisionFileExt))
+}
+
+func revisionNumber(fileName string) (int, bool) {
+	if !strings.HasPrefix(fileName, revisionFilePrefix) || filepath.Ext(fileName) != revisionFileExt {
+		return 0, false
+	}
+	number, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(fileName, revisionFilePrefix), revisionFileExt))
+	return number, err == nil
+}
@@ -0,0 +1,102 @@
+package release
+
+import (
+	"github.com/go-test/deep"
+	"os"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	s := Store{Path: t.TempDir()}
+
+	revisions := []Revision{
+		{Release: "test", Status: Deployed, Description: "Install complete", Manifests: "kind: Pipeline\n"},
+		{Release: "test", Status: Failed, Description: "Upgrade complete failed"},
+		{Release: "test", Status: Deployed, Description: "Upgrade complete", Values: map[interface{}]interface{}{"account": "prod"}},
+	}
+	for i, revision := range revisions {
+		recorded, err := s.Record(revision)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if recorded.Number != i+1 {
+			t.Errorf("expected revision %d, got %d", i+1, recorded.Number)
+		}
+	}
+
+	history, err := s.History("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make([][]interface{}, 0)
+	for _, revision := range history {
+		result = append(result, []interface{}{revision.Number, revision.Status, revision.Description})
+	}
+	expected := [][]interface{}{
+		{1, Superseded, "Install complete"},
+		{2, Failed, "Upgrade complete failed"},
+		{3, Deployed, "Upgrade complete"},
+	}
+	if diff := deep.Equal(result, expected); diff != nil {
+		t.Error(diff)
+	}
+
+	revision, err := s.Get("test", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(revision.Manifests, "kind: Pipeline\n"); diff != nil {
+		t.Error(diff)
+	}
+	if _, err = s.Get("test", 4); err == nil {
+		t.Error("expected a missing revision error")
+	}
+}
+
+func TestHistoryUnknownRelease(t *testing.T) {
+	s := Store{Path: t.TempDir()}
+	history, err := s.History("missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 0 {
+		t.Errorf("expected no revisions, got %v", history)
+	}
+	if _, err = s.History("../escape"); err == nil {
+		t.Error("expected an invalid release name error")
+	}
+}
+
+func TestRecordUninstall(t *testing.T) {
+	s := Store{Path: t.TempDir()}
+	for _, revision := range []Revision{
+		{Release: "test", Status: Deployed, Description: "Install complete", Manifests: "kind: Pipeline\n"},
+		{Release: "test", Status: Uninstalled, Description: "Uninstall complete"},
+	} {
+		if _, err := s.Record(revision); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := s.History("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make([]string, 0)
+	for _, revision := range history {
+		statuses = append(statuses, revision.Status)
+	}
+	if diff := deep.Equal(statuses, []string{Superseded, Uninstalled}); diff != nil {
+		t.Error(diff)
+	}
+
+	for path, perm := range map[string]os.FileMode{s.releasePath("test"): dirPerm, s.revisionPath("test", 1): filePerm} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != perm {
+			t.Errorf("expected %v to have permissions %v, got %v", path, perm, info.Mode().Perm())
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package schema
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"swinch/domain/application"
+	"swinch/domain/pipeline"
+	"swinch/domain/stages"
+)
+
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// Schema is a JSON Schema generated from the Go types decoding the manifests
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Const                string             `json:"const,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	If                   *Schema            `json:"if,omitempty"`
+	Then                 *Schema            `json:"then,omitempty"`
+}
+
+// Kinds returns the schema of each manifest kind
+func Kinds() map[string]*Schema {
+	return map[string]*Schema{
+		application.Kind: Application(),
+		pipeline.Kind:    Pipeline(),
+	}
+}
+
+// Application returns the schema of the Application manifests
+func Application() *Schema {
+	s := manifestSchema(application.Kind, application.API, reflect.TypeOf(application.Manifest{}))
+	s.Properties["metadata"].Required = []string{"name"}
+	return s
+}
+
+// Pipeline returns the schema of the Pipeline manifests, the stages are validated by type
+func Pipeline() *Schema {
+	s := manifestSchema(pipeline.Kind, pipeline.API, reflect.TypeOf(pipeline.Manifest{}))
+	s.Properties["metadata"].Required = []string{"name", "application"}
+	s.Properties["spec"].Properties["stages"].Items = Stage()
+	return s
+}
+
+// Manifest returns the schema of all the manifest kinds, selected by the manifest kind
+func Manifest() *Schema {
+	s := &Schema{Schema: Draft, Title: "swinch manifest", Type: "object", Required: []string{"apiVersion", "kind"}}
+	kinds := Kinds()
+	for _, kind := range sortedKeys(kinds) {
+		kindSchema := *kinds[kind]
+		kindSchema.Schema = ""
+		s.AllOf = append(s.AllOf, &Schema{If: constProperty("kind", kind), Then: &kindSchema})
+	}
+	return s
+}
+
+// Stage returns the schema of the pipeline stages, the fields common to all stages and the fields of each stage type
+func Stage() *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema), Required: []string{"name", "type"}}
+	addProperties(s, reflect.TypeOf(stages.Metadata{}))
+	addProperties(s, reflect.TypeOf(stages.Common{}))
+	s.Properties["ifStageFails"].Enum = []string{stages.HaltPipeline, stages.HaltBranch, stages.HaltBranchAndFail, stages.IgnoreStageFailure}
+
+	ss := stages.Stages{}
+	ss.GetTypes()
+	types := make(map[string]*Schema)
+	for stageType, stage := range ss.Types {
+		types[string(stageType)] = FromType(reflect.TypeOf(stage))
+	}
+	for _, stageType := range sortedKeys(types) {
+		s.AllOf = append(s.AllOf, &Schema{If: constProperty("type", stageType), Then: types[stageType]})
+	}
+	return s
+}
+
+func manifestSchema(kind, api string, t reflect.Type) *Schema {
+	s := FromType(t)
+	s.Schema = Draft
+	s.Title = kind
+	s.Required = []string{"apiVersion", "kind", "metadata", "spec"}
+	s.Properties["apiVersion"].Const = api
+	s.Properties["kind"].Const = kind
+	return s
+}
+
+// FromType returns the schema of a Go type, the struct fields are named by their yaml tag, or json tag if missing
+// the fields excluded from yaml are left out and the embedded structs are inlined, as decoded by mapstructure squash
+func FromType(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return FromType(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: FromType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: FromType(t.Elem())}
+	case reflect.Struct:
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addProperties(s, t)
+		return s
+	default:
+		return &Schema{}
+	}
+}
+
+func addProperties(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			addProperties(s, field.Type)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name, ok := fieldName(field); ok {
+			s.Properties[name] = FromType(field.Type)
+		}
+	}
+}
+
+func fieldName(field reflect.StructField) (string, bool) {
+	if tag, ok := field.Tag.Lookup("yaml"); ok && strings.Split(tag, ",")[0] != "" {
+		name := strings.Split(tag, ",")[0]
+		return name, name != "-"
+	}
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		name := strings.Split(tag, ",")[0]
+		return name, name != "-" && name != ""
+	}
+	return "", false
+}
+
+func constProperty(key, value string) *Schema {
+	return &Schema{Properties: map[string]*Schema{key: {Const: value}}, Required: []string{key}}
+}
+
+func sortedKeys(schemas map[string]*Schema) []string {
+	keys := make([]string, 0)
+	for key := range schemas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
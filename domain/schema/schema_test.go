@@ -0,0 +1,84 @@
+package schema
+
+import (
+	"errors"
+	"github.com/go-test/deep"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	_ "swinch/testing"
+	"testing"
+)
+
+func TestPipelineSchema(t *testing.T) {
+	stage := Pipeline().Properties["spec"].Properties["stages"].Items
+	if stage == nil || len(stage.AllOf) == 0 {
+		t.Fatal("expected the stage schemas by type")
+	}
+	wait := stage.AllOf[len(stage.AllOf)-1]
+	if wait.If.Properties["type"].Const != "wait" {
+		t.Fatalf("expected the stage types sorted, got %v", wait.If.Properties["type"].Const)
+	}
+	if wait.Then.Properties["waitTime"].Type != "integer" {
+		t.Error("expected the wait stage waitTime integer")
+	}
+	if _, ok := wait.Then.Properties["refId"]; !ok {
+		t.Error("expected the embedded stage metadata inlined")
+	}
+}
+
+func TestValidateManifests(t *testing.T) {
+	files, err := filepath.Glob("test/manifests/*/*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		document := yaml.Node{}
+		if err = yaml.Unmarshal(data, &document); err != nil {
+			t.Fatal(err)
+		}
+		if err = Validate(&document); err != nil {
+			t.Errorf("%v: %v", file, err)
+		}
+	}
+}
+
+const invalidPipeline = `apiVersion: spinnaker.adobe.com/alpha1
+kind: Pipeline
+metadata:
+  name: invalid
+spec:
+  limitConcurrent: "yes please"
+  stages:
+    - name: wait
+      type: wait
+      waitTime: "30"
+    - name: wait again
+      type: wait
+      waitTime:
+        seconds: 30
+`
+
+func TestValidate(t *testing.T) {
+	document := yaml.Node{}
+	if err := yaml.Unmarshal([]byte(invalidPipeline), &document); err != nil {
+		t.Fatal(err)
+	}
+	var validationError *ValidationError
+	if !errors.As(Validate(&document), &validationError) {
+		t.Fatal("expected a validation error")
+	}
+
+	expected := []FieldError{
+		{Line: 4, Manifest: "Pipeline/invalid", Path: "metadata", Message: "missing required key 'application'"},
+		{Line: 6, Manifest: "Pipeline/invalid", Path: "spec.limitConcurrent", Message: "expected a boolean, got 'yes please'"},
+		{Line: 14, Manifest: "Pipeline/invalid", Path: "spec.stages[1].waitTime", Message: "expected an integer, got an object"},
+	}
+	if diff := deep.Equal(validationError.Errors, expected); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package schema
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"strconv"
+	"strings"
+)
+
+const nullTag = "!!null"
+
+// FieldError is a manifest value not matching the schema, File is set for the manifests read from files
+type FieldError struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line"`
+	Manifest string `json:"manifest"`
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	location := fmt.Sprintf("line %d", e.Line)
+	if e.File != "" {
+		location = fmt.Sprintf("%v:%d", e.File, e.Line)
+	}
+	return fmt.Sprintf("%v: %v: %v: %v", location, e.Manifest, e.Path, e.Message)
+}
+
+// ValidationError lists every schema error found in the manifests
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0)
+	for _, fieldError := range e.Errors {
+		lines = append(lines, fieldError.String())
+	}
+	return fmt.Sprintf("manifests do not match the schema:\n  %v", strings.Join(lines, "\n  "))
+}
+
+// Validate checks a manifest yaml document against the schema of its kind, every error is reported with its path and line in a ValidationError
+// the scalars are checked as decoded with mapstructure WeaklyTypedInput, numbers and booleans can be written as strings
+// the documents of unknown kinds are left to the manifest validation
+func Validate(document *yaml.Node) error {
+	node := document
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	kind := mappingValue(node, "kind")
+	if kind == nil {
+		return nil
+	}
+	s, ok := Kinds()[kind.Value]
+	if !ok {
+		return nil
+	}
+
+	name := kind.Value
+	if metadataName := mappingValue(mappingValue(node, "metadata"), "name"); metadataName != nil {
+		name = fmt.Sprintf("%v/%v", kind.Value, metadataName.Value)
+	}
+	errs := make([]FieldError, 0)
+	s.validate(node, "", name, &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: errs}
+}
+
+func (s *Schema) validate(node *yaml.Node, path, manifest string, errs *[]FieldError) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == nullTag {
+		return
+	}
+	report := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Line: node.Line, Manifest: manifest, Path: displayPath(path), Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch s.Type {
+	case "object":
+		if node.Kind != yaml.MappingNode {
+			report("expected an object, got %v", describe(node))
+			return
+		}
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			report("expected a list, got %v", describe(node))
+			return
+		}
+	case "string", "integer", "number", "boolean":
+		if node.Kind != yaml.ScalarNode {
+			report("expected %v, got %v", article(s.Type), describe(node))
+			return
+		}
+		if !weaklyTyped(s.Type, node.Value) {
+			report("expected %v, got '%v'", article(s.Type), node.Value)
+			return
+		}
+	}
+
+	if s.Const != "" && node.Value != s.Const {
+		report("expected '%v', got '%v'", s.Const, node.Value)
+	}
+	if len(s.Enum) > 0 && !contains(s.Enum, node.Value) {
+		report("expected one of '%v', got '%v'", strings.Join(s.Enum, "', '"), node.Value)
+	}
+
+	if node.Kind == yaml.MappingNode {
+		for _, key := range s.Required {
+			if mappingValue(node, key) == nil {
+				report("missing required key '%v'", key)
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			if property, ok := s.Properties[key]; ok {
+				property.validate(value, joinPath(path, key), manifest, errs)
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.validate(value, joinPath(path, key), manifest, errs)
+			}
+		}
+	}
+	if node.Kind == yaml.SequenceNode && s.Items != nil {
+		for i, item := range node.Content {
+			s.Items.validate(item, fmt.Sprintf("%v[%d]", path, i), manifest, errs)
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		if sub.If != nil {
+			ifErrs := make([]FieldError, 0)
+			sub.If.validate(node, path, manifest, &ifErrs)
+			if len(ifErrs) != 0 {
+				continue
+			}
+			sub.Then.validate(node, path, manifest, errs)
+			continue
+		}
+		sub.validate(node, path, manifest, errs)
+	}
+}
+
+// weaklyTyped reports if a scalar is decoded in the type, numbers and booleans are converted to each other
+// and empty strings decode to zero values
+func weaklyTyped(schemaType, value string) bool {
+	if value == "" || schemaType == "string" {
+		return true
+	}
+	if _, err := strconv.ParseBool(value); err == nil {
+		return true
+	}
+	if _, err := strconv.ParseInt(value, 0, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func describe(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "an object"
+	case yaml.SequenceNode:
+		return "a list"
+	default:
+		return fmt.Sprintf("'%v'", node.Value)
+	}
+}
+
+func article(schemaType string) string {
+	switch schemaType {
+	case "integer":
+		return "an integer"
+	case "boolean":
+		return "a boolean"
+	default:
+		return "a " + schemaType
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -14,13 +14,14 @@ package stages
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/mitchellh/mapstructure"
-	log "github.com/sirupsen/logrus"
+	"strconv"
 	"swinch/domain/datastore"
 	"swinch/domain/util"
 )
 
-const bakeManifest StageType = "bakeManifest"
+const BakeManifestType StageType = "bakeManifest"
 
 type BakeManifest struct {
 	Metadata `mapstructure:",squash"`
@@ -33,7 +34,7 @@ type BakeManifest struct {
 	ManifestArtifactId          string              `json:"manifestArtifactId,omitempty"`
 	Namespace                   string              `json:"namespace"`
 	TemplateRenderer            string              `json:"templateRenderer"`
-	Overrides                   struct{}            `yaml:"overrides,omitempty" json:"overrides,omitempty"`
+	Overrides                   Values              `yaml:"overrides,omitempty" json:"overrides"`
 	RawOverrides                bool                `yaml:"rawOverrides,omitempty" json:"rawOverrides,omitempty"`
 	EvaluateOverrideExpressions bool                `yaml:"evaluateOverrideExpressions,omitempty" json:"evaluateOverrideExpressions,omitempty"`
 }
@@ -74,37 +75,47 @@ type InputArtifacts struct {
 	} `yaml:"artifact" json:"artifact"`
 }
 
-func (bm BakeManifest) MakeStage(stage *Stage) *map[string]interface{} {
-	bm.decode(stage)
-	bm.expand()
+func (bm BakeManifest) MakeStage(stage *Stage) (*map[string]interface{}, error) {
+	err := bm.decode(stage)
+	if err != nil {
+		return nil, err
+	}
+	err = bm.expand()
+	if err != nil {
+		return nil, err
+	}
 	return bm.encode()
 }
 
-func (bm *BakeManifest) decode(stage *Stage) {
+func (bm *BakeManifest) decode(stage *Stage) error {
 	decoderConfig := mapstructure.DecoderConfig{WeaklyTypedInput: true, Result: &bm}
 	decoder, err := mapstructure.NewDecoder(&decoderConfig)
 	if err != nil {
-		log.Fatalf("err: %v", err)
+		return err
 	}
 
 	err = decoder.Decode(stage.Metadata)
 	if err != nil {
-		log.Fatalf("error decoding stage metadata: %v", err)
+		return fmt.Errorf("error decoding stage metadata: %w", err)
 	}
 	err = decoder.Decode(stage.Spec)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
 	err = decoder.Decode(stage.Common)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
+	return nil
 }
 
-func (bm *BakeManifest) expand() {
+func (bm *BakeManifest) expand() error {
 	u := util.Util{}
 
 	// TODO check that index on ExpectedArtifacts is always 0
+	if len(bm.ExpectedArtifacts) == 0 {
+		return fmt.Errorf("bake stage '%v' has no expectedArtifacts", bm.Name)
+	}
 	expectArtifacts := &bm.ExpectedArtifacts[0]
 	// expectArtifacts ID is used by the deploy stage
 	expectArtifacts.Id = u.GenerateUUID(expectArtifacts.DisplayName + bm.Name).String()
@@ -113,19 +124,92 @@ func (bm *BakeManifest) expand() {
 	//expectArtifacts.MatchArtifact.Id = bm.newUUID(expectArtifacts.MatchArtifact.Name+expectArtifacts.MatchArtifact.Type).String()
 
 	// TODO check that index on InputArtifacts is always 0
+	if len(bm.InputArtifacts) == 0 {
+		return fmt.Errorf("bake stage '%v' has no inputArtifacts", bm.Name)
+	}
 	inputArtifacts := &bm.InputArtifacts[0]
 	//Deduplicate ArtifactAccount name
 	inputArtifacts.Artifact.ArtifactAccount = inputArtifacts.Account
 	// inputArtifacts.Artifact.Id not mandatory
 	//inputArtifacts.Artifact.Id = bm.newUUID(inputArtifacts.Artifact.Name + inputArtifacts.Artifact.Version).String()
+	return nil
+}
+
+// ImportStage strips the artifact ids and the deduplicated artifact account from a Spinnaker bake stage
+func (bm BakeManifest) ImportStage(stage *Stage) {
+	initStage := *stage.InitStage
+	deleteEmptyMap(initStage, "overrides")
+	for _, expectedArtifact := range mapList(initStage["expectedArtifacts"]) {
+		delete(expectedArtifact, "id")
+		if matchArtifact, ok := expectedArtifact["matchArtifact"].(map[string]interface{}); ok {
+			delete(matchArtifact, "id")
+		}
+	}
+
+	for _, inputArtifact := range mapList(initStage["inputArtifacts"]) {
+		artifact, ok := inputArtifact["artifact"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		delete(artifact, "id")
+		// The artifact account is deduplicated from the input artifact account on expand
+		if _, ok := inputArtifact["account"]; !ok {
+			inputArtifact["account"] = artifact["artifactAccount"]
+		}
+		delete(artifact, "artifactAccount")
+	}
 }
 
-func (bm *BakeManifest) encode() *map[string]interface{} {
+// bakeRefId returns the swinch refId of the bake stage producing the expected artifact id
+func bakeRefId(allStages []map[string]interface{}, artifactId interface{}) (int, bool) {
+	for i, stage := range allStages {
+		if stage["type"] != string(BakeManifestType) {
+			continue
+		}
+		for _, expectedArtifact := range mapList(stage["expectedArtifacts"]) {
+			if expectedArtifact["id"] == artifactId {
+				return i + 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// bakeStageIndex returns the index of the bake stage a deploy stage is bound to
+// the bake is presumed to be the first element in RequisiteStageRefIds if not bound explicitly
+func bakeStageIndex(stageName string, requisiteStageRefIds []string, bakeStageRefIds *int, allStages []map[string]interface{}) (int, error) {
+	var bakeStageIndex int
+	if bakeStageRefIds == nil {
+		if len(requisiteStageRefIds) == 0 {
+			return 0, fmt.Errorf("stage '%v' has no requisiteStageRefIds to bind the bake stage", stageName)
+		}
+		var err error
+		bakeStageIndex, err = strconv.Atoi(requisiteStageRefIds[0])
+		if err != nil {
+			return 0, fmt.Errorf("stage '%v' has an invalid requisiteStageRefId: %v", stageName, requisiteStageRefIds[0])
+		}
+	} else {
+		bakeStageIndex = *bakeStageRefIds
+	}
+	// Convert from Spinnaker human-readable indexing
+	bakeStageIndex -= 1
+
+	if bakeStageIndex < 0 || bakeStageIndex >= len(allStages) {
+		return 0, fmt.Errorf("stage '%v' is bound to an unknown bake stage refId: %v", stageName, bakeStageIndex+1)
+	}
+	return bakeStageIndex, nil
+}
+
+func (bm *BakeManifest) encode() (*map[string]interface{}, error) {
 	d := datastore.Datastore{}
 	stage := new(map[string]interface{})
-	err := json.Unmarshal(d.MarshalJSON(bm), stage)
+	stageJSON, err := d.MarshalJSON(bm)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(stageJSON, stage)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal JSON:  %v", err)
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
-	return stage
+	return stage, nil
 }
@@ -14,8 +14,8 @@ package stages
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/mitchellh/mapstructure"
-	log "github.com/sirupsen/logrus"
 	"swinch/domain/datastore"
 )
 
@@ -51,31 +51,35 @@ type Options struct {
 	GracePeriodSeconds int  `yaml:"gracePeriodSeconds" json:"gracePeriodSeconds"`
 }
 
-func (delm DeleteManifest) MakeStage(stage *Stage) *map[string]interface{} {
-	delm.decode(stage)
+func (delm DeleteManifest) MakeStage(stage *Stage) (*map[string]interface{}, error) {
+	err := delm.decode(stage)
+	if err != nil {
+		return nil, err
+	}
 	delm.expand(stage)
 	return delm.encode()
 }
 
-func (delm *DeleteManifest) decode(stage *Stage) {
+func (delm *DeleteManifest) decode(stage *Stage) error {
 	decoderConfig := mapstructure.DecoderConfig{WeaklyTypedInput: true, Result: &delm}
 	decoder, err := mapstructure.NewDecoder(&decoderConfig)
 	if err != nil {
-		log.Fatalf("err: %v", err)
+		return err
 	}
 
 	err = decoder.Decode(stage.Metadata)
 	if err != nil {
-		log.Fatalf("error decoding stage metadata: %v", err)
+		return fmt.Errorf("error decoding stage metadata: %w", err)
 	}
 	err = decoder.Decode(stage.Spec)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
 	err = decoder.Decode(stage.Common)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
+	return nil
 }
 
 func (delm *DeleteManifest) expand(stage *Stage) {
@@ -87,12 +91,26 @@ func (delm *DeleteManifest) expand(stage *Stage) {
 	}
 }
 
-func (delm *DeleteManifest) encode() *map[string]interface{} {
+// ImportStage strips the application and folds the Spinnaker location back in the namespace
+func (delm DeleteManifest) ImportStage(stage *Stage) {
+	initStage := *stage.InitStage
+	delete(initStage, "app")
+	if location, ok := initStage["location"]; ok {
+		initStage["namespace"] = location
+		delete(initStage, "location")
+	}
+}
+
+func (delm *DeleteManifest) encode() (*map[string]interface{}, error) {
 	d := datastore.Datastore{}
 	stage := new(map[string]interface{})
-	err := json.Unmarshal(d.MarshalJSON(delm), stage)
+	stageJSON, err := d.MarshalJSON(delm)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(stageJSON, stage)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal JSON:  %v", err)
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
-	return stage
+	return stage, nil
 }
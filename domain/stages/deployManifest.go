@@ -14,13 +14,14 @@ package stages
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/mitchellh/mapstructure"
 	log "github.com/sirupsen/logrus"
 	"strconv"
 	"swinch/domain/datastore"
 )
 
-const deployManifest StageType = "deployManifest"
+const DeployManifestType StageType = "deployManifest"
 
 type DeployManifest struct {
 	Metadata `mapstructure:",squash"`
@@ -31,13 +32,15 @@ type DeployManifest struct {
 	ManifestArtifactId       string   `json:"manifestArtifactId"`
 	Moniker                  *Moniker `yaml:"moniker,omitempty" json:"moniker"`
 	NamespaceOverride        string   `json:"namespaceOverride"`
-	Overrides                struct{} `yaml:"overrides,omitempty" json:"overrides,omitempty"`
+	Overrides                Values   `yaml:"overrides,omitempty" json:"overrides"`
 	Source                   string   `json:"source"`
 	SkipExpressionEvaluation bool     `yaml:"skipExpressionEvaluation,omitempty" json:"skipExpressionEvaluation,omitempty"`
 
 	StageTimeoutMs *int `yaml:"stageTimeoutMs,omitempty" json:"stageTimeoutMs,omitempty"`
 
 	BakeStageRefIds *int `yaml:"bakeStageRefIds,omitempty" json:"-"`
+	// BakeStage binds the bake stage by name, resolved to bakeStageRefIds
+	BakeStage string `yaml:"bakeStage,omitempty" json:"-"`
 }
 
 // Moniker is part of Stages
@@ -45,67 +48,88 @@ type Moniker struct {
 	App string `yaml:"app" json:"app"`
 }
 
-func (dm DeployManifest) MakeStage(stage *Stage) *map[string]interface{} {
-	dm.decode(stage)
-	dm.expand(stage)
+func (dm DeployManifest) MakeStage(stage *Stage) (*map[string]interface{}, error) {
+	err := dm.decode(stage)
+	if err != nil {
+		return nil, err
+	}
+	err = dm.expand(stage)
+	if err != nil {
+		return nil, err
+	}
 	return dm.encode()
 }
 
-func (dm *DeployManifest) decode(stage *Stage) {
+func (dm *DeployManifest) decode(stage *Stage) error {
 	decoderConfig := mapstructure.DecoderConfig{WeaklyTypedInput: true, Result: &dm}
 	decoder, err := mapstructure.NewDecoder(&decoderConfig)
 	if err != nil {
-		log.Fatalf("err: %v", err)
+		return err
 	}
 
 	err = decoder.Decode(stage.Metadata)
 	if err != nil {
-		log.Fatalf("error decoding stage metadata: %v", err)
+		return fmt.Errorf("error decoding stage metadata: %w", err)
 	}
 	err = decoder.Decode(stage.Spec)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
 	err = decoder.Decode(stage.Common)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
+	return nil
 }
 
-func (dm *DeployManifest) expand(stage *Stage) {
+func (dm *DeployManifest) expand(stage *Stage) error {
 	dm.Moniker = new(Moniker)
 	dm.Moniker.App = stage.ManifestMetadata.Application
 
-	bakeIndex := dm.getBakeIndex()
+	bakeIndex, err := bakeStageIndex(dm.Name, dm.RequisiteStageRefIds, dm.BakeStageRefIds, *stage.AllStages)
+	if err != nil {
+		return err
+	}
 	bake := new(BakeManifest)
-	err := mapstructure.Decode((*stage.AllStages)[bakeIndex], bake)
+	err = mapstructure.Decode((*stage.AllStages)[bakeIndex], bake)
 	if err != nil {
-		log.Fatalf("err: %v", err)
+		return err
+	}
+	if len(bake.ExpectedArtifacts) == 0 {
+		return fmt.Errorf("stage '%v' is bound to stage '%v' which has no expectedArtifacts", dm.Name, bake.Name)
 	}
 	dm.ManifestArtifactId = bake.ExpectedArtifacts[0].Id
+	return nil
 }
 
-func (dm *DeployManifest) getBakeIndex() int {
-	bakeStageIndex := new(int)
-	// Bind deploy stage to a specific bake
-	if dm.BakeStageRefIds == nil {
-		// Presume a deploy stage has the bake stage as the first element in RequisiteStageRefIds
-		*bakeStageIndex, _ = strconv.Atoi(dm.RequisiteStageRefIds[0])
-	} else {
-		*bakeStageIndex = *dm.BakeStageRefIds
-	}
-	// Convert from Spinnaker human-readable indexing
-	*bakeStageIndex -= 1
+// ImportStage strips the moniker and binds the manifestArtifactId back to the bake stage producing the artifact
+func (dm DeployManifest) ImportStage(stage *Stage) {
+	initStage := *stage.InitStage
+	delete(initStage, "moniker")
+	deleteEmptyMap(initStage, "overrides")
 
-	return *bakeStageIndex
+	bakeIndex, ok := bakeRefId(*stage.AllStages, initStage["manifestArtifactId"])
+	if !ok {
+		log.Warnf("Failed to find the bake stage for the manifest artifact of stage '%v'", stage.Metadata.Name)
+		return
+	}
+	delete(initStage, "manifestArtifactId")
+	// The bake is presumed to be the first element in RequisiteStageRefIds if not bound explicitly
+	if len(stage.RequisiteStageRefIds) == 0 || stage.RequisiteStageRefIds[0] != strconv.Itoa(bakeIndex) {
+		initStage["bakeStageRefIds"] = bakeIndex
+	}
 }
 
-func (dm *DeployManifest) encode() *map[string]interface{} {
+func (dm *DeployManifest) encode() (*map[string]interface{}, error) {
 	d := datastore.Datastore{}
 	stage := new(map[string]interface{})
-	err := json.Unmarshal(d.MarshalJSON(dm), stage)
+	stageJSON, err := d.MarshalJSON(dm)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(stageJSON, stage)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal JSON:  %v", err)
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
-	return stage
+	return stage, nil
 }
@@ -14,8 +14,8 @@ package stages
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/mitchellh/mapstructure"
-	log "github.com/sirupsen/logrus"
 	"swinch/domain/datastore"
 )
 
@@ -43,38 +43,46 @@ type EthosNamespaceCreateParameters struct {
 	SpinnakerOnlyGroup string `yaml:"SpinnakerOnlyGroup" json:"SpinnakerOnlyGroup"`
 }
 
-func (enc EthosNamespaceCreate) MakeStage(stage *Stage) *map[string]interface{} {
-	enc.decode(stage)
+func (enc EthosNamespaceCreate) MakeStage(stage *Stage) (*map[string]interface{}, error) {
+	err := enc.decode(stage)
+	if err != nil {
+		return nil, err
+	}
 	return enc.encode()
 }
 
-func (enc *EthosNamespaceCreate) decode(stage *Stage) {
+func (enc *EthosNamespaceCreate) decode(stage *Stage) error {
 	decoderConfig := mapstructure.DecoderConfig{WeaklyTypedInput: true, Result: &enc}
 	decoder, err := mapstructure.NewDecoder(&decoderConfig)
 	if err != nil {
-		log.Fatalf("err: %v", err)
+		return err
 	}
 
 	err = decoder.Decode(stage.Metadata)
 	if err != nil {
-		log.Fatalf("error decoding stage metadata: %v", err)
+		return fmt.Errorf("error decoding stage metadata: %w", err)
 	}
 	err = decoder.Decode(stage.Spec)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
 	err = decoder.Decode(stage.Common)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
+	return nil
 }
 
-func (enc *EthosNamespaceCreate) encode() *map[string]interface{} {
+func (enc *EthosNamespaceCreate) encode() (*map[string]interface{}, error) {
 	d := datastore.Datastore{}
 	stage := new(map[string]interface{})
-	err := json.Unmarshal(d.MarshalJSON(enc), stage)
+	stageJSON, err := d.MarshalJSON(enc)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(stageJSON, stage)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal JSON:  %v", err)
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
-	return stage
+	return stage, nil
 }
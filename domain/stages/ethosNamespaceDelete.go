@@ -14,8 +14,8 @@ package stages
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/mitchellh/mapstructure"
-	log "github.com/sirupsen/logrus"
 	"swinch/domain/datastore"
 )
 
@@ -36,38 +36,46 @@ type EthosNamespaceDeleteParameters struct {
 	Project   string `yaml:"Project" json:"Project"`
 }
 
-func (end EthosNamespaceDelete) MakeStage(stage *Stage) *map[string]interface{} {
-	end.decode(stage)
+func (end EthosNamespaceDelete) MakeStage(stage *Stage) (*map[string]interface{}, error) {
+	err := end.decode(stage)
+	if err != nil {
+		return nil, err
+	}
 	return end.encode()
 }
 
-func (end *EthosNamespaceDelete) decode(stage *Stage) {
+func (end *EthosNamespaceDelete) decode(stage *Stage) error {
 	decoderConfig := mapstructure.DecoderConfig{WeaklyTypedInput: true, Result: &end}
 	decoder, err := mapstructure.NewDecoder(&decoderConfig)
 	if err != nil {
-		log.Fatalf("err: %v", err)
+		return err
 	}
 
 	err = decoder.Decode(stage.Metadata)
 	if err != nil {
-		log.Fatalf("error decoding stage metadata: %v", err)
+		return fmt.Errorf("error decoding stage metadata: %w", err)
 	}
 	err = decoder.Decode(stage.Spec)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
 	err = decoder.Decode(stage.Common)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
+	return nil
 }
 
-func (end *EthosNamespaceDelete) encode() *map[string]interface{} {
+func (end *EthosNamespaceDelete) encode() (*map[string]interface{}, error) {
 	d := datastore.Datastore{}
 	stage := new(map[string]interface{})
-	err := json.Unmarshal(d.MarshalJSON(end), stage)
+	stageJSON, err := d.MarshalJSON(end)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(stageJSON, stage)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal JSON:  %v", err)
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
-	return stage
+	return stage, nil
 }
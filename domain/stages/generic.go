@@ -0,0 +1,99 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package stages
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/mitchellh/mapstructure"
+	"swinch/domain/datastore"
+)
+
+// PassThroughConfig configures the stage types swinch doesn't model, like checkPreconditions, webhook or plugin stages
+// when enabled they are made by the Generic stage type, any type or only the types of the allowlist if set
+type PassThroughConfig struct {
+	Enabled   bool
+	Allowlist []string
+}
+
+// Allows returns true if a stage type not modeled by swinch can be passed through
+func (pc PassThroughConfig) Allows(stageType StageType) bool {
+	if !pc.Enabled {
+		return false
+	}
+	if len(pc.Allowlist) == 0 {
+		return true
+	}
+	for _, allowed := range pc.Allowlist {
+		if string(stageType) == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Generic makes the stages of any type, the metadata and common fields are processed as for the other stage types
+// the stage specific keys are passed through to Spinnaker unchanged
+type Generic struct {
+	Metadata `mapstructure:",squash"`
+	Common   `mapstructure:",squash"`
+
+	Spec map[string]interface{} `mapstructure:",remain" yaml:"-" json:"-"`
+}
+
+func (g Generic) MakeStage(stage *Stage) (*map[string]interface{}, error) {
+	err := g.decode(stage)
+	if err != nil {
+		return nil, err
+	}
+	return g.encode()
+}
+
+func (g *Generic) decode(stage *Stage) error {
+	decoderConfig := mapstructure.DecoderConfig{WeaklyTypedInput: true, Result: &g}
+	decoder, err := mapstructure.NewDecoder(&decoderConfig)
+	if err != nil {
+		return err
+	}
+
+	err = decoder.Decode(stage.Metadata)
+	if err != nil {
+		return fmt.Errorf("error decoding stage metadata: %w", err)
+	}
+	err = decoder.Decode(stage.Common)
+	if err != nil {
+		return fmt.Errorf("error decoding stage spec: %w", err)
+	}
+	g.Spec = stage.Spec
+	return nil
+}
+
+func (g *Generic) encode() (*map[string]interface{}, error) {
+	d := datastore.Datastore{}
+	stage := new(map[string]interface{})
+	stageJSON, err := d.MarshalJSON(g)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(stageJSON, stage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	// The Metadata and Common keys are already set, only the stage specific keys are added
+	for key, value := range g.Spec {
+		if _, ok := (*stage)[key]; !ok {
+			(*stage)[key] = value
+		}
+	}
+	return stage, nil
+}
@@ -14,8 +14,8 @@ package stages
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/mitchellh/mapstructure"
-	log "github.com/sirupsen/logrus"
 	"swinch/domain/datastore"
 )
 
@@ -25,48 +25,56 @@ type Jenkins struct {
 	Metadata `mapstructure:",squash"`
 	Common   `mapstructure:",squash"`
 
-	IsNew                    bool     `yaml:"isNew,omitempty" json:"isNew,omitempty"`
-	Master                   string   `yaml:"master" json:"master"`
-	Job                      string   `yaml:"job" json:"job"`
-	Parameters               struct{} `yaml:"parameters" json:"parameters"`
-	MarkUnstableAsSuccessful bool     `yaml:"markUnstableAsSuccessful" json:"markUnstableAsSuccessful"`
-	WaitForCompletion        bool     `yaml:"waitForCompletion" json:"waitForCompletion"`
+	IsNew                    bool   `yaml:"isNew,omitempty" json:"isNew,omitempty"`
+	Master                   string `yaml:"master" json:"master"`
+	Job                      string `yaml:"job" json:"job"`
+	Parameters               Values `yaml:"parameters" json:"parameters"`
+	MarkUnstableAsSuccessful bool   `yaml:"markUnstableAsSuccessful" json:"markUnstableAsSuccessful"`
+	WaitForCompletion        bool   `yaml:"waitForCompletion" json:"waitForCompletion"`
 
 	StageTimeoutMs *int `yaml:"stageTimeoutMs,omitempty" json:"stageTimeoutMs,omitempty"`
 }
 
-func (jks Jenkins) MakeStage(stage *Stage) *map[string]interface{} {
-	jks.decode(stage)
+func (jks Jenkins) MakeStage(stage *Stage) (*map[string]interface{}, error) {
+	err := jks.decode(stage)
+	if err != nil {
+		return nil, err
+	}
 	return jks.encode()
 }
 
-func (jks *Jenkins) decode(stage *Stage) {
+func (jks *Jenkins) decode(stage *Stage) error {
 	decoderConfig := mapstructure.DecoderConfig{WeaklyTypedInput: true, Result: &jks}
 	decoder, err := mapstructure.NewDecoder(&decoderConfig)
 	if err != nil {
-		log.Fatalf("err: %v", err)
+		return err
 	}
 
 	err = decoder.Decode(stage.Metadata)
 	if err != nil {
-		log.Fatalf("error decoding stage metadata: %v", err)
+		return fmt.Errorf("error decoding stage metadata: %w", err)
 	}
 	err = decoder.Decode(stage.Spec)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
 	err = decoder.Decode(stage.Common)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
+	return nil
 }
 
-func (jks *Jenkins) encode() *map[string]interface{} {
+func (jks *Jenkins) encode() (*map[string]interface{}, error) {
 	d := datastore.Datastore{}
 	stage := new(map[string]interface{})
-	err := json.Unmarshal(d.MarshalJSON(jks), stage)
+	stageJSON, err := d.MarshalJSON(jks)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(stageJSON, stage)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal JSON:  %v", err)
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
-	return stage
+	return stage, nil
 }
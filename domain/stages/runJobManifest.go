@@ -14,6 +14,7 @@ package stages
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/mitchellh/mapstructure"
 	log "github.com/sirupsen/logrus"
 	"strconv"
@@ -39,68 +40,89 @@ type RunJobManifest struct {
 	StageTimeoutMs *int `yaml:"stageTimeoutMs,omitempty" json:"stageTimeoutMs,omitempty"`
 
 	JobBakeStageRefIds *int `yaml:"jobBakeStageRefIds,omitempty" json:"-"`
+	// JobBakeStage binds the bake stage by name, resolved to jobBakeStageRefIds
+	JobBakeStage string `yaml:"jobBakeStage,omitempty" json:"-"`
 }
 
-func (rjm RunJobManifest) MakeStage(stage *Stage) *map[string]interface{} {
-	rjm.decode(stage)
-	rjm.expand(stage)
+func (rjm RunJobManifest) MakeStage(stage *Stage) (*map[string]interface{}, error) {
+	err := rjm.decode(stage)
+	if err != nil {
+		return nil, err
+	}
+	err = rjm.expand(stage)
+	if err != nil {
+		return nil, err
+	}
 	return rjm.encode()
 }
 
-func (rjm *RunJobManifest) decode(stage *Stage) {
+func (rjm *RunJobManifest) decode(stage *Stage) error {
 	decoderConfig := mapstructure.DecoderConfig{WeaklyTypedInput: true, Result: &rjm}
 	decoder, err := mapstructure.NewDecoder(&decoderConfig)
 	if err != nil {
-		log.Fatalf("err: %v", err)
+		return err
 	}
 
 	err = decoder.Decode(stage.Metadata)
 	if err != nil {
-		log.Fatalf("error decoding stage metadata: %v", err)
+		return fmt.Errorf("error decoding stage metadata: %w", err)
 	}
 	err = decoder.Decode(stage.Spec)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
 	err = decoder.Decode(stage.Common)
 	if err != nil {
-		log.Fatalf("error decoding stage spec: %v", err)
+		return fmt.Errorf("error decoding stage spec: %w", err)
 	}
+	return nil
 }
 
-func (rjm *RunJobManifest) expand(stage *Stage) {
-	bakeIndex := rjm.getBakeIndex()
+func (rjm *RunJobManifest) expand(stage *Stage) error {
+	bakeIndex, err := bakeStageIndex(rjm.Name, rjm.RequisiteStageRefIds, rjm.JobBakeStageRefIds, *stage.AllStages)
+	if err != nil {
+		return err
+	}
 	//TODO get the bake stage without decoding
 	bake := new(BakeManifest)
-	err := mapstructure.Decode((*stage.AllStages)[bakeIndex], bake)
+	err = mapstructure.Decode((*stage.AllStages)[bakeIndex], bake)
 	if err != nil {
-		log.Fatalf("err: %v", err)
+		return err
+	}
+	if len(bake.ExpectedArtifacts) == 0 {
+		return fmt.Errorf("stage '%v' is bound to stage '%v' which has no expectedArtifacts", rjm.Name, bake.Name)
 	}
 
 	rjm.ManifestArtifactId = bake.ExpectedArtifacts[0].Id
+	return nil
 }
 
-func (rjm *RunJobManifest) getBakeIndex() int {
-	bakeStageIndex := new(int)
-	// Bind deploy stage to a specific bake
-	if rjm.JobBakeStageRefIds == nil {
-		// Presume a deployment stage has the bake stage as the first element in RequisiteStageRefIds
-		*bakeStageIndex, _ = strconv.Atoi(rjm.RequisiteStageRefIds[0])
-	} else {
-		*bakeStageIndex = *rjm.JobBakeStageRefIds
-	}
-	// Convert from Spinnaker human-readable indexing
-	*bakeStageIndex -= 1
+// ImportStage binds the manifestArtifactId back to the bake stage producing the job artifact
+func (rjm RunJobManifest) ImportStage(stage *Stage) {
+	initStage := *stage.InitStage
 
-	return *bakeStageIndex
+	bakeIndex, ok := bakeRefId(*stage.AllStages, initStage["manifestArtifactId"])
+	if !ok {
+		log.Warnf("Failed to find the bake stage for the manifest artifact of stage '%v'", stage.Metadata.Name)
+		return
+	}
+	delete(initStage, "manifestArtifactId")
+	// The bake is presumed to be the first element in RequisiteStageRefIds if not bound explicitly
+	if len(stage.RequisiteStageRefIds) == 0 || stage.RequisiteStageRefIds[0] != strconv.Itoa(bakeIndex) {
+		initStage["jobBakeStageRefIds"] = bakeIndex
+	}
 }
 
-func (rjm *RunJobManifest) encode() *map[string]interface{} {
+func (rjm *RunJobManifest) encode() (*map[string]interface{}, error) {
 	d := datastore.Datastore{}
 	stage := new(map[string]interface{})
-	err := json.Unmarshal(d.MarshalJSON(rjm), stage)
+	stageJSON, err := d.MarshalJSON(rjm)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(stageJSON, stage)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal JSON:  %v", err)
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
-	return stage
+	return stage, nil
 }
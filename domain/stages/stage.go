@@ -13,8 +13,17 @@ governing permissions and limitations under the License.
 package stages
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/mitchellh/mapstructure"
-	log "github.com/sirupsen/logrus"
+)
+
+// ifStageFails options, as seen in the WebUI
+const (
+	HaltPipeline       = "halt the entire pipeline"
+	HaltBranch         = "halt this branch of the pipeline"
+	HaltBranchAndFail  = "halt this branch and fail the pipeline once other branches complete"
+	IgnoreStageFailure = "ignore the failure"
 )
 
 type Stage struct {
@@ -44,6 +53,9 @@ type Metadata struct {
 	Type                 string   `yaml:"type,omitempty" json:"type,omitempty"`
 	RefId                string   `yaml:"refId,omitempty" json:"refId,omitempty"`
 	RequisiteStageRefIds []string `yaml:"requisiteStageRefIds" json:"requisiteStageRefIds"`
+
+	// DependsOn key only in swinch, the names of the required stages resolved to requisiteStageRefIds
+	DependsOn []string `yaml:"dependsOn,omitempty" json:"-"`
 }
 
 type Common struct {
@@ -61,6 +73,20 @@ type Common struct {
 	Notifications     *Notifications `yaml:"notifications,omitempty" json:"notifications,omitempty"`
 
 	Comments string `yaml:"comments,omitempty" json:"comments,omitempty"`
+
+	// Strict key only in swinch, unknown stage keys fail the stage unless strict is false, then the unknown keys are passed through to Spinnaker
+	Strict *bool `yaml:"strict,omitempty" json:"-"`
+}
+
+// Values is a free form map of a stage, like the Jenkins job parameters, any key is passed to Spinnaker
+type Values map[string]interface{}
+
+// MarshalJSON encodes unset Values as an empty object, as saved by Spinnaker
+func (v Values) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]interface{}(v))
 }
 
 type StageFails struct {
@@ -113,19 +139,38 @@ type Message struct {
 	} `yaml:"stageStarting,omitempty" json:"stage.starting,omitempty"`
 }
 
-func (s Stage) Decode(stage *map[string]interface{}) Stage {
+func (s Stage) Decode(stage *map[string]interface{}) (Stage, error) {
 	tmp := new(Stage)
 	decoderConfig := mapstructure.DecoderConfig{WeaklyTypedInput: true, Result: &tmp}
 	decoder, err := mapstructure.NewDecoder(&decoderConfig)
 	if err != nil {
-		log.Fatalf("err: %v", err)
+		return Stage{}, err
 	}
 
 	err = decoder.Decode(stage)
 	if err != nil {
-		log.Fatalf("err: %v", err)
+		return Stage{}, fmt.Errorf("error decoding stage '%v': %w", (*stage)["name"], err)
+	}
+	return *tmp, nil
+}
+
+// mapList returns the maps found in a decoded JSON or YAML list
+func mapList(data interface{}) []map[string]interface{} {
+	maps := make([]map[string]interface{}, 0)
+	list, _ := data.([]interface{})
+	for _, item := range list {
+		if m, ok := item.(map[string]interface{}); ok {
+			maps = append(maps, m)
+		}
+	}
+	return maps
+}
+
+// deleteEmptyMap removes a key holding an empty map, swinch encodes some empty structs that are optional in Spinnaker
+func deleteEmptyMap(stage map[string]interface{}, key string) {
+	if m, ok := stage[key].(map[string]interface{}); ok && len(m) == 0 {
+		delete(stage, key)
 	}
-	return *tmp
 }
 
 // FailStageSetter method reduces the complexity of "If stage fails" execution option
@@ -137,19 +182,19 @@ func (s *Stage) FailStageSetter() {
 	s.CompleteOtherBranchesThenFail = new(bool)
 
 	switch s.IfStageFails {
-	case "halt the entire pipeline":
+	case HaltPipeline:
 		*s.ContinuePipeline = false
 		*s.FailPipeline = true
 		*s.CompleteOtherBranchesThenFail = false
-	case "halt this branch of the pipeline":
+	case HaltBranch:
 		*s.ContinuePipeline = false
 		*s.FailPipeline = false
 		*s.CompleteOtherBranchesThenFail = false
-	case "halt this branch and fail the pipeline once other branches complete":
+	case HaltBranchAndFail:
 		*s.ContinuePipeline = false
 		*s.FailPipeline = false
 		*s.CompleteOtherBranchesThenFail = true
-	case "ignore the failure":
+	case IgnoreStageFailure:
 		*s.ContinuePipeline = true
 		*s.FailPipeline = false
 		*s.CompleteOtherBranchesThenFail = false
@@ -161,3 +206,37 @@ func (s *Stage) FailStageSetter() {
 		*s.CompleteOtherBranchesThenFail = false
 	}
 }
+
+// FailStageGetter method reverses FailStageSetter, folding the Spinnaker bool combination back in the ifStageFails parameter
+// Spinnaker fails the pipeline when failPipeline is missing, same as the swinch default
+func (s *Stage) FailStageGetter() {
+	continuePipeline := s.ContinuePipeline != nil && *s.ContinuePipeline
+	failPipeline := s.FailPipeline == nil || *s.FailPipeline
+	completeOtherBranchesThenFail := s.CompleteOtherBranchesThenFail != nil && *s.CompleteOtherBranchesThenFail
+
+	switch {
+	case continuePipeline:
+		s.IfStageFails = IgnoreStageFailure
+	case completeOtherBranchesThenFail:
+		s.IfStageFails = HaltBranchAndFail
+	case failPipeline:
+		// default option, no need to set it in the manifest
+		s.IfStageFails = ""
+	default:
+		s.IfStageFails = HaltBranch
+	}
+}
+
+// ImportCommon method strips the common data generated by swinch from the initial stage map
+func (s *Stage) ImportCommon() {
+	s.FailStageGetter()
+
+	stage := *s.InitStage
+	delete(stage, "refId")
+	delete(stage, "continuePipeline")
+	delete(stage, "failPipeline")
+	delete(stage, "completeOtherBranchesThenFail")
+	if s.IfStageFails != "" {
+		stage["ifStageFails"] = s.IfStageFails
+	}
+}
@@ -17,8 +17,24 @@ type Stages struct {
 
 type StageType string
 
+// AccountStageTypes deploy to a Spinnaker account, the account can't be empty
+var AccountStageTypes = map[StageType]bool{DeployManifestType: true, deleteManifest: true, runJobManifest: true}
+
+// ProcessOptions configure how the pipeline stages are processed
+type ProcessOptions struct {
+	PassThrough PassThroughConfig
+	// Lenient passes the unknown keys of the stages without a strict key through, instead of failing them
+	Lenient bool
+}
+
 type S interface {
-	MakeStage(*Stage) *map[string]interface{}
+	MakeStage(*Stage) (*map[string]interface{}, error)
+}
+
+// I is implemented by the stage types that expand swinch fields into Spinnaker generated data,
+// ImportStage reverts the expansion in place on the Stage InitStage map
+type I interface {
+	ImportStage(*Stage)
 }
 
 func (ss *Stages) addStageDefinition(stageType StageType, stage S) {
@@ -27,9 +43,9 @@ func (ss *Stages) addStageDefinition(stageType StageType, stage S) {
 
 func (ss *Stages) GetTypes() {
 	ss.Types = make(map[StageType]S)
-	ss.addStageDefinition(bakeManifest, BakeManifest{})
+	ss.addStageDefinition(BakeManifestType, BakeManifest{})
 	ss.addStageDefinition(deleteManifest, DeleteManifest{})
-	ss.addStageDefinition(deployManifest, DeployManifest{})
+	ss.addStageDefinition(DeployManifestType, DeployManifest{})
 	ss.addStageDefinition(jenkins, Jenkins{})
 	ss.addStageDefinition(manualJudgment, ManualJudgment{})
 	ss.addStageDefinition(pipeline, Pipeline{})
@@ -38,3 +54,20 @@ func (ss *Stages) GetTypes() {
 	ss.addStageDefinition(ethosNamespaceCreate, EthosNamespaceCreate{})
 	ss.addStageDefinition(ethosNamespaceDelete, EthosNamespaceDelete{})
 }
+
+// GetType returns the stage definition of a stage type, the types not modeled by swinch get the Generic definition if passed through
+func (ss *Stages) GetType(stageType StageType, passThrough PassThroughConfig) (S, bool) {
+	if stage, ok := ss.Types[stageType]; ok {
+		return stage, true
+	}
+	if passThrough.Allows(stageType) {
+		return Generic{}, true
+	}
+	return nil, false
+}
+
+// GetImporter returns the import definition of a stage type, if the type has one
+func (ss *Stages) GetImporter(stageType StageType) (I, bool) {
+	importer, ok := ss.Types[stageType].(I)
+	return importer, ok
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package stages
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// UnknownKey is a stage key not decoded by the stage type, Closest is the closest valid key at the same level
+type UnknownKey struct {
+	Path    string
+	Closest string
+}
+
+func (k UnknownKey) String() string {
+	if k.Closest == "" {
+		return fmt.Sprintf("unknown key '%v'", k.Path)
+	}
+	return fmt.Sprintf("unknown key '%v', did you mean '%v'?", k.Path, k.Closest)
+}
+
+// UnknownKeys returns the keys of a stage, nested keys included, that are not decoded by the stage type and would be dropped
+// the keys are matched with the stage type fields case insensitively, as decoded by mapstructure
+func UnknownKeys(stageType S, stage map[string]interface{}) []UnknownKey {
+	unknown := make([]UnknownKey, 0)
+	unknownKeys(reflect.TypeOf(stageType), stage, "", &unknown)
+	return unknown
+}
+
+func unknownKeys(t reflect.Type, data interface{}, path string, unknown *[]UnknownKey) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := make(map[string]reflect.StructField)
+		names := make([]string, 0)
+		open := structFields(t, fields, &names)
+
+		keys := make([]string, 0)
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			field, ok := fields[strings.ToLower(key)]
+			if !ok {
+				if open {
+					continue
+				}
+				*unknown = append(*unknown, UnknownKey{Path: keyPath, Closest: closest(key, names)})
+				continue
+			}
+			unknownKeys(field.Type, m[key], keyPath, unknown)
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := data.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range list {
+			unknownKeys(t.Elem(), item, fmt.Sprintf("%v[%d]", path, i), unknown)
+		}
+	}
+}
+
+// structFields indexes the fields decoded by mapstructure by lower case name, the squashed structs are inlined
+// names are the keys written in the manifests, to suggest the closest key
+// a struct with a remain field decodes any key and is open
+func structFields(t reflect.Type, fields map[string]reflect.StructField, names *[]string) bool {
+	open := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("mapstructure"), ",")
+		if len(tag) > 1 && tag[1] == "remain" {
+			open = true
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && len(tag) > 1 && tag[1] == "squash" {
+			open = structFields(field.Type, fields, names) || open
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag[0] != "" {
+			name = tag[0]
+		}
+		fields[strings.ToLower(name)] = field
+		if manifestName, ok := manifestKey(field); ok {
+			*names = append(*names, manifestName)
+		}
+	}
+	return open
+}
+
+// manifestKey returns the key of a field in the manifests, the fields generated by swinch have no key
+func manifestKey(field reflect.StructField) (string, bool) {
+	for _, tagName := range []string{"yaml", "json"} {
+		if name := strings.Split(field.Tag.Get(tagName), ",")[0]; name != "" {
+			return name, name != "-"
+		}
+	}
+	return strings.ToLower(field.Name[:1]) + field.Name[1:], true
+}
+
+// closest returns the name closest to the key, if close enough to be a typo
+func closest(key string, names []string) string {
+	best, bestDistance := "", len(key)/2+1
+	for _, name := range names {
+		distance := levenshtein(strings.ToLower(key), strings.ToLower(name))
+		if distance < bestDistance {
+			best, bestDistance = name, distance
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// Package swinch renders swinch charts and syncs the manifests with Spinnaker, for tools embedding swinch
+// the package keeps no global state, every Spinnaker call goes through the Client backends
+package swinch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"swinch/domain/application"
+	"swinch/domain/change"
+	"swinch/domain/chart"
+	"swinch/domain/manifest"
+	"swinch/domain/pipeline"
+	"swinch/domain/stages"
+	"swinch/spincli"
+)
+
+// Manifest is a swinch manifest, an Application or a Pipeline
+type Manifest = manifest.Manifest
+
+// Change is the planned change of an Application or a Pipeline
+type Change = change.Change
+
+// StageOptions configure how the pipeline stages are processed, like the stage types passed through to Spinnaker
+type StageOptions = stages.ProcessOptions
+
+// Client plans and applies manifests on the Spinnaker backends, it is safe for concurrent use once configured
+type Client struct {
+	Applications spincli.ApplicationBackend
+	Pipelines    spincli.PipelineBackend
+	StageOptions StageOptions
+}
+
+// Option configures a client created with New
+type Option func(*Client)
+
+// Plan holds the changes syncing Spinnaker with the manifests, in the order they are applied
+type Plan struct {
+	Changes []PlannedChange
+}
+
+// PlannedChange is a planned change with the Application or Pipeline saving it
+type PlannedChange struct {
+	Change
+
+	resource resource
+}
+
+// Result reports the changes applied and the changes which failed
+type Result struct {
+	Applied []Change
+	Failed  []Failure
+}
+
+type Failure struct {
+	Change Change
+	Err    error
+}
+
+// resource is a loaded Application or Pipeline
+type resource interface {
+	Change(ctx context.Context) (change.Change, error)
+	Save(ctx context.Context) error
+}
+
+// New returns a client syncing the manifests through a Gate client, the client has no Spinnaker backends
+// if gate is nil and WithBackends is not set, the Gate client is never read from the swinch config file
+func New(gate *spincli.GateClient, options ...Option) *Client {
+	c := &Client{}
+	if gate != nil {
+		g := spincli.Gate{Client: gate}
+		c.Applications = &spincli.ApplicationAPI{Gate: g}
+		c.Pipelines = &spincli.PipelineAPI{Gate: g}
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// WithBackends stores the applications and pipelines in other backends than Gate, like the spincli in-memory Spinnaker
+func WithBackends(applications spincli.ApplicationBackend, pipelines spincli.PipelineBackend) Option {
+	return func(c *Client) {
+		c.Applications = applications
+		c.Pipelines = pipelines
+	}
+}
+
+// WithPassThroughStages passes the stage types not modeled by swinch through to Spinnaker, every stage type if none is listed
+func WithPassThroughStages(stageTypes ...string) Option {
+	return func(c *Client) {
+		c.StageOptions.PassThrough = stages.PassThroughConfig{Enabled: true, Allowlist: stageTypes}
+	}
+}
+
+// WithLenientStages passes the unknown keys of the stages without a strict key through to Spinnaker, instead of failing them
+func WithLenientStages() Option {
+	return func(c *Client) {
+		c.StageOptions.Lenient = true
+	}
+}
+
+// Render renders a chart with its default values overwritten by the values files, in order
+func Render(chartPath string, valuesFiles []string) ([]Manifest, error) {
+	t := chart.Template{}
+	renderedTemplates, err := t.RenderChart(chartPath, strings.Join(valuesFiles, ","), false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := new(bytes.Buffer)
+	for _, renderedTemplate := range renderedTemplates {
+		buffer.Write(renderedTemplate.Buffer.Bytes())
+	}
+	m := manifest.Manifest{}
+	return m.Decode(buffer)
+}
+
+// Plan compares the manifests with Spinnaker, applications are planned before pipelines
+func (c *Client) Plan(ctx context.Context, manifests []Manifest) (Plan, error) {
+	if c.Applications == nil || c.Pipelines == nil {
+		return Plan{}, errors.New("the client has no Spinnaker backends")
+	}
+
+	plan := Plan{}
+	for _, kind := range []string{application.Kind, pipeline.Kind} {
+		for _, m := range manifests {
+			if m.Kind != kind {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return Plan{}, err
+			}
+
+			r, err := c.load(m)
+			if err != nil {
+				return Plan{}, fmt.Errorf("%v: %w", m.Name(), err)
+			}
+			planned, err := r.Change(ctx)
+			if err != nil {
+				return Plan{}, fmt.Errorf("%v: %w", m.Name(), err)
+			}
+			plan.Changes = append(plan.Changes, PlannedChange{Change: planned, resource: r})
+		}
+	}
+	return plan, nil
+}
+
+// Summary counts the planned changes by action
+func (p Plan) Summary() change.Summary {
+	return p.changes().Summary()
+}
+
+// HasChanges reports if applying the plan changes Spinnaker
+func (p Plan) HasChanges() bool {
+	return p.changes().HasChanges()
+}
+
+// Write prints the plan in one of the change formats, like change.TableFormat
+func (p Plan) Write(w io.Writer, format string) error {
+	return p.changes().Write(w, format)
+}
+
+func (p Plan) changes() change.Plan {
+	changes := change.Plan{}
+	for _, planned := range p.Changes {
+		changes.Add(planned.Change)
+	}
+	return changes
+}
+
+// Apply saves the planned changes, a failing change is reported and the next changes are still applied
+// returns an error if any change failed or if the context is done
+func (c *Client) Apply(ctx context.Context, plan Plan) (Result, error) {
+	result := Result{}
+	for _, planned := range plan.Changes {
+		if planned.Action == change.NoOp {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		err := errors.New("the change was not planned by a client")
+		if planned.resource != nil {
+			err = planned.resource.Save(ctx)
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, Failure{Change: planned.Change, Err: err})
+			continue
+		}
+		result.Applied = append(result.Applied, planned.Change)
+	}
+
+	if len(result.Failed) > 0 {
+		return result, fmt.Errorf("%v of %v changes failed to apply", len(result.Failed), len(result.Failed)+len(result.Applied))
+	}
+	return result, nil
+}
+
+func (c *Client) load(m Manifest) (resource, error) {
+	switch m.Kind {
+	case application.Kind:
+		a := &application.Application{Backend: c.Applications}
+		return a.Load(m)
+	case pipeline.Kind:
+		p := &pipeline.Pipeline{Backend: c.Pipelines, StageOptions: c.StageOptions}
+		return p.Load(m)
+	default:
+		return nil, fmt.Errorf("unknown manifest Kind: %v", m.Kind)
+	}
+}
@@ -0,0 +1,178 @@
+package swinch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-test/deep"
+	"swinch/domain/change"
+	"swinch/domain/stages"
+	"swinch/spincli"
+	"swinch/spincli/gatetest"
+	_ "swinch/testing"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRenderPlanApply(t *testing.T) {
+	memory := spincli.NewMemory()
+	if err := memory.Applications().Save(context.Background(), "test", map[string]interface{}{"name": "test"}); err != nil {
+		t.Fatal(err)
+	}
+	client := New(nil, WithBackends(memory.Applications(), memory.Pipelines()))
+
+	manifests, err := Render("test/charts/test_template", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 1 {
+		t.Fatalf("expected 1 rendered manifest, got %d", len(manifests))
+	}
+
+	plan, err := client.Plan(context.Background(), manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(actions(plan), []change.Action{change.Create}); diff != nil {
+		t.Error(diff)
+	}
+
+	result, err := client.Apply(context.Background(), plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Applied) != 1 || len(result.Failed) != 0 {
+		t.Errorf("expected 1 applied change, got %+v", result)
+	}
+
+	plan, err = client.Plan(context.Background(), manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(actions(plan), []change.Action{change.NoOp}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPlanWithoutBackends(t *testing.T) {
+	_, err := New(nil).Plan(context.Background(), nil)
+	if err == nil {
+		t.Error("expected an error for a client without backends")
+	}
+}
+
+func TestApplyPlannedChanges(t *testing.T) {
+	memory := spincli.NewMemory()
+	client := New(nil, WithBackends(memory.Applications(), memory.Pipelines()))
+	manifests := []Manifest{
+		{ApiVersion: "spinnaker.adobe.com/alpha1", Kind: "Application", Metadata: map[string]interface{}{"name": "first"}},
+		{ApiVersion: "spinnaker.adobe.com/alpha1", Kind: "Application", Metadata: map[string]interface{}{"name": "second"}},
+	}
+	plan, err := client.Plan(context.Background(), manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each change saves its own application, whatever its position in the plan
+	plan.Changes = plan.Changes[1:]
+	if _, err = client.Apply(context.Background(), plan); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := memory.Applications().Get(context.Background(), "first")
+	second, _ := memory.Applications().Get(context.Background(), "second")
+	if len(first) != 0 || len(second) == 0 {
+		t.Errorf("expected only the second application saved, got %s and %s", first, second)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = client.Apply(ctx, plan); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cancelled context error, got %v", err)
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	client := New(&spincli.GateClient{Endpoint: "https://gate.example.com"}, WithPassThroughStages("webhook"), WithLenientStages())
+	if client.Applications == nil || client.Pipelines == nil {
+		t.Error("expected the Gate backends")
+	}
+	expected := StageOptions{PassThrough: stages.PassThroughConfig{Enabled: true, Allowlist: []string{"webhook"}}, Lenient: true}
+	if diff := deep.Equal(client.StageOptions, expected); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func actions(plan Plan) []change.Action {
+	actions := make([]change.Action, 0)
+	for _, c := range plan.Changes {
+		actions = append(actions, c.Action)
+	}
+	return actions
+}
+
+func TestClientConcurrentUse(t *testing.T) {
+	gate := gatetest.NewServer()
+	defer gate.Close()
+	client := New(gate.Client())
+	apply := func(m Manifest) error {
+		plan, err := client.Plan(context.Background(), []Manifest{m})
+		if err != nil {
+			return err
+		}
+		_, err = client.Apply(context.Background(), plan)
+		return err
+	}
+	if err := apply(Manifest{ApiVersion: "spinnaker.adobe.com/alpha1", Kind: "Application", Metadata: map[string]interface{}{"name": "test"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the client is shared by the goroutines, run with -race
+	errs := make([]error, 8)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = apply(Manifest{
+				ApiVersion: "spinnaker.adobe.com/alpha1",
+				Kind:       "Pipeline",
+				Metadata:   map[string]interface{}{"name": fmt.Sprintf("pipeline-%d", i), "application": "test"},
+				Spec:       map[string]interface{}{"stages": []interface{}{}},
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		pipeName := fmt.Sprintf("pipeline-%d", i)
+		if err != nil {
+			t.Errorf("%v: %v", pipeName, err)
+		} else if gate.Pipeline("test", pipeName) == nil {
+			t.Errorf("expected pipeline '%v' saved", pipeName)
+		}
+	}
+}
+
+func TestClientThrottled(t *testing.T) {
+	gate := gatetest.NewServer()
+	defer gate.Close()
+	gateClient := gate.Client()
+	gateClient.RetryDelay = time.Millisecond
+	client := New(gateClient)
+
+	plan, err := client.Plan(context.Background(), []Manifest{
+		{ApiVersion: "spinnaker.adobe.com/alpha1", Kind: "Application", Metadata: map[string]interface{}{"name": "test"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a burst of throttled requests is retried
+	gate.Throttle(3)
+	if _, err = client.Apply(context.Background(), plan); err != nil {
+		t.Fatal(err)
+	}
+	if gate.Application("test") == nil {
+		t.Error("expected the application saved")
+	}
+}
@@ -13,68 +13,77 @@ governing permissions and limitations under the License.
 package spincli
 
 import (
+	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
-	"swinch/cmd/config"
+	"net/http"
 )
 
+// ApplicationAPI keeps no request state, it is shared by concurrent calls
 type ApplicationAPI struct {
-	appName string
-	SpinCLI
+	Gate
 }
 
-var baseArgs = []string{
-	"--no-color=false",
-	"--config", config.HomeFolder()+config.CfgFolderName+config.CfgSpinFileName,
+func (a ApplicationAPI) NotFound(appName string) error {
+	return fmt.Errorf("Application '%v' not found\n", appName)
 }
 
-func (a *ApplicationAPI) NotFound() error {
-	return fmt.Errorf("Application '%v' not found\n", a.appName)
+func (a ApplicationAPI) deleteNotFound(appName string) error {
+	return fmt.Errorf("attempting to delete application '%v' which does not exist, exiting", appName)
 }
 
-func (a *ApplicationAPI) deleteNotFound() error {
-	return fmt.Errorf("attempting to delete application '%v' which does not exist, exiting", a.appName)
-}
-
-func (a *ApplicationAPI) Get(appName string) []byte {
-	a.appName = appName
-	args := []string{"application", "get", a.appName}
-	buffer, err := a.executeAppCmd(append(baseArgs, args...))
-	a.status(err)
-	return buffer.Bytes()
+func (a ApplicationAPI) Get(ctx context.Context, appName string) ([]byte, error) {
+	gate, err := a.gate()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := gate.timeoutContext(ctx)
+	defer cancel()
+	app, err := gate.GetApplication(ctx, appName)
+	if StatusCode(err) == http.StatusNotFound {
+		log.Info(a.NotFound(appName))
+		return nil, nil
+	}
+	return app, a.status(appName, err)
 }
 
-func (a ApplicationAPI) Save(appName, filePath string) {
-	a.appName = appName
-	args := []string{"application", "save", "--file", filePath}
-	_, err := a.executeAppCmd(append(baseArgs, args...))
-	a.status(err)
-	if err == nil {
-		log.Infof("Application '%v' updated successfuly", a.appName)
+func (a ApplicationAPI) Save(ctx context.Context, appName string, spec interface{}) error {
+	gate, err := a.gate()
+	if err != nil {
+		return err
 	}
-	defer a.rmTmp(filePath)
+	ctx, cancel := gate.timeoutContext(ctx)
+	defer cancel()
+	err = gate.SaveApplication(ctx, appName, spec)
+	if err != nil {
+		return a.status(appName, err)
+	}
+	log.Infof("Application '%v' updated successfuly", appName)
+	return nil
 }
 
-func (a ApplicationAPI) Delete(appName string) {
-	a.appName = appName
-	args := []string{"application", "delete", a.appName}
-	_, err := a.executeAppCmd(append(baseArgs, args...))
+func (a ApplicationAPI) Delete(ctx context.Context, appName string) error {
+	gate, err := a.gate()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := gate.timeoutContext(ctx)
+	defer cancel()
+	err = gate.DeleteApplication(ctx, appName)
+	if StatusCode(err) == http.StatusNotFound {
+		log.Info(a.deleteNotFound(appName))
+		return nil
+	}
 	if err != nil {
-		a.status(err)
-	} else {
-		log.Infof("Delete application '%v' success", a.appName)
+		return a.status(appName, err)
 	}
+	log.Infof("Delete application '%v' success", appName)
+	return nil
 }
 
-func (a *ApplicationAPI) status(err error) {
+func (a ApplicationAPI) status(appName string, err error) error {
 	if err != nil {
-		switch err.Error() {
-		case a.NotFound().Error():
-			log.Info(a.NotFound())
-		case a.deleteNotFound().Error():
-			log.Info(a.deleteNotFound())
-		default:
-			log.Fatalf("Failed to check application status: %v", err)
-		}
+		return fmt.Errorf("failed to check application '%v' status: %w", appName, err)
 	}
+	return nil
 }
@@ -0,0 +1,30 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package spincli
+
+import "context"
+
+// ApplicationBackend stores the Spinnaker applications, Get returns an empty result for missing applications
+type ApplicationBackend interface {
+	Get(ctx context.Context, appName string) ([]byte, error)
+	Save(ctx context.Context, appName string, spec interface{}) error
+	Delete(ctx context.Context, appName string) error
+}
+
+// PipelineBackend stores the Spinnaker pipelines, Get returns an empty result for missing pipelines
+type PipelineBackend interface {
+	Get(ctx context.Context, appName, pipeName string) ([]byte, error)
+	List(ctx context.Context, appName string) ([]byte, error)
+	Save(ctx context.Context, appName, pipeName string, spec interface{}) error
+	Delete(ctx context.Context, appName, pipeName string) error
+}
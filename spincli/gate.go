@@ -0,0 +1,449 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package spincli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTimeout    = 60 * time.Second
+	DefaultMaxRetries = 5
+	DefaultRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 30 * time.Second
+
+	LdapAuth  = "ldap"
+	BasicAuth = "basic"
+
+	taskPollInterval = time.Second
+
+	// throttledMessage is the body of the 403 Gate returns for a burst of requests
+	throttledMessage = "request repeated too quickly"
+)
+
+var (
+	taskCompleted = map[string]bool{"SUCCEEDED": true, "STOPPED": true, "SKIPPED": true, "TERMINAL": true, "FAILED_CONTINUE": true}
+	taskSucceeded = map[string]bool{"SUCCEEDED": true, "STOPPED": true, "SKIPPED": true}
+	// throttled status codes are retried with an exponential backoff, see Throttled
+	throttled = map[int]bool{http.StatusTooManyRequests: true, http.StatusServiceUnavailable: true}
+)
+
+// GateError is returned for Gate responses with a non 2xx status code
+type GateError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *GateError) Error() string {
+	return fmt.Sprintf("%v %v: %d %v", e.Method, e.URL, e.StatusCode, strings.TrimSpace(string(e.Body)))
+}
+
+// StatusCode returns the Gate response status code of an error, 0 if the error is not a Gate response
+func StatusCode(err error) int {
+	var gateErr *GateError
+	if errors.As(err, &gateErr) {
+		return gateErr.StatusCode
+	}
+	return 0
+}
+
+// Throttled returns true for the Gate throttling responses: 429, 503 and the 403 Gate returns with the
+// "Request repeated too quickly" message for a burst of requests, any other 403 is a denied access
+func Throttled(err error) bool {
+	var gateErr *GateError
+	if !errors.As(err, &gateErr) {
+		return false
+	}
+	if gateErr.StatusCode == http.StatusForbidden {
+		return strings.Contains(strings.ToLower(string(gateErr.Body)), throttledMessage)
+	}
+	return throttled[gateErr.StatusCode]
+}
+
+// GateClient is a Gate REST API client, every call is bound to a context
+// throttled calls are retried MaxRetries times, DefaultMaxRetries if not set and never if negative
+// a 404 reading a path the client wrote is retried the same way, until the Spinnaker caches see the write
+type GateClient struct {
+	Endpoint   string
+	Auth       string
+	Username   string
+	Password   string
+	Timeout    time.Duration
+	MaxRetries int
+	RetryDelay time.Duration
+	HTTPClient *http.Client
+
+	clientOnce sync.Once
+	loginMutex sync.Mutex
+	loggedIn   bool
+
+	writtenMutex sync.Mutex
+	written      map[string]bool
+}
+
+// timeoutContext returns a context bound to the client timeout, cancelled with the parent context
+func (g *GateClient) timeoutContext(parent context.Context) (context.Context, context.CancelFunc) {
+	timeout := g.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	return context.WithTimeout(parent, timeout)
+}
+
+// GetApplication returns the Spinnaker application attributes
+func (g *GateClient) GetApplication(ctx context.Context, appName string) ([]byte, error) {
+	body, err := g.do(ctx, http.MethodGet, applicationPath(appName), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	app := new(struct {
+		Attributes json.RawMessage `json:"attributes"`
+	})
+	err = json.Unmarshal(body, app)
+	if err != nil {
+		return nil, err
+	}
+	return app.Attributes, nil
+}
+
+// SaveApplication creates or updates an application through an orca task
+func (g *GateClient) SaveApplication(ctx context.Context, appName string, spec interface{}) error {
+	err := g.runTask(ctx, map[string]interface{}{
+		"job":         []interface{}{map[string]interface{}{"type": "createApplication", "application": spec}},
+		"application": appName,
+		"description": fmt.Sprintf("Create Application: %s", appName),
+	})
+	if err == nil {
+		g.wrote(applicationPath(appName))
+	}
+	return err
+}
+
+// DeleteApplication deletes an application through an orca task, a missing application returns the Gate not found error
+func (g *GateClient) DeleteApplication(ctx context.Context, appName string) error {
+	_, err := g.GetApplication(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	err = g.runTask(ctx, map[string]interface{}{
+		"job":         []interface{}{map[string]interface{}{"type": "deleteApplication", "application": map[string]interface{}{"name": appName}}},
+		"application": appName,
+		"description": fmt.Sprintf("Delete Application: %s", appName),
+	})
+	if err == nil {
+		// the application pipelines are deleted with it
+		g.deleted(applicationPath(appName), "/applications/"+url.PathEscape(appName)+"/")
+	}
+	return err
+}
+
+// GetPipeline returns the pipeline config
+func (g *GateClient) GetPipeline(ctx context.Context, appName, pipeName string) ([]byte, error) {
+	return g.do(ctx, http.MethodGet, pipelinePath(appName, pipeName), nil)
+}
+
+// ListPipelines returns the configs of all the application pipelines
+func (g *GateClient) ListPipelines(ctx context.Context, appName string) ([]byte, error) {
+	return g.do(ctx, http.MethodGet, "/applications/"+url.PathEscape(appName)+"/pipelineConfigs", nil)
+}
+
+// SavePipeline creates or updates a pipeline, the Spinnaker pipeline id is reused for existing pipelines
+func (g *GateClient) SavePipeline(ctx context.Context, appName, pipeName string, spec interface{}) error {
+	pipeline := make(map[string]interface{})
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(specJSON, &pipeline)
+	if err != nil {
+		return err
+	}
+
+	existingPipe, err := g.GetPipeline(ctx, appName, pipeName)
+	switch {
+	case err == nil:
+		existing := new(struct {
+			Id string `json:"id"`
+		})
+		if err = json.Unmarshal(existingPipe, existing); err != nil {
+			return err
+		}
+		pipeline["id"] = existing.Id
+	case StatusCode(err) == http.StatusNotFound:
+		// new pipeline, Spinnaker generates the id
+	default:
+		return err
+	}
+
+	_, err = g.do(ctx, http.MethodPost, "/pipelines", pipeline)
+	if err == nil {
+		g.wrote(pipelinePath(appName, pipeName))
+	}
+	return err
+}
+
+// DeletePipeline deletes a pipeline
+func (g *GateClient) DeletePipeline(ctx context.Context, appName, pipeName string) error {
+	_, err := g.do(ctx, http.MethodDelete, "/pipelines/"+url.PathEscape(appName)+"/"+url.PathEscape(pipeName), nil)
+	if err == nil {
+		g.deleted(pipelinePath(appName, pipeName))
+	}
+	return err
+}
+
+// runTask submits an orca task and waits for it to complete
+func (g *GateClient) runTask(ctx context.Context, task map[string]interface{}) error {
+	body, err := g.do(ctx, http.MethodPost, "/tasks", task)
+	if err != nil {
+		return err
+	}
+	taskRef := new(struct {
+		Ref string `json:"ref"`
+	})
+	err = json.Unmarshal(body, taskRef)
+	if err != nil {
+		return err
+	}
+	g.wrote(taskRef.Ref)
+
+	for {
+		body, err = g.do(ctx, http.MethodGet, taskRef.Ref, nil)
+		if err != nil {
+			return err
+		}
+		status := new(struct {
+			Status string `json:"status"`
+		})
+		err = json.Unmarshal(body, status)
+		if err != nil {
+			return err
+		}
+
+		if taskCompleted[status.Status] {
+			if !taskSucceeded[status.Status] {
+				return fmt.Errorf("task '%v' failed with status %v", task["description"], status.Status)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("task '%v' did not complete: %w", task["description"], ctx.Err())
+		case <-time.After(taskPollInterval):
+		}
+	}
+}
+
+func (g *GateClient) do(ctx context.Context, method, path string, data interface{}) ([]byte, error) {
+	err := g.login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataJSON []byte
+	if data != nil {
+		dataJSON, err = json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		body, retryAfter, err := g.request(ctx, method, path, dataJSON)
+		if !(Throttled(err) || g.readAfterWrite(method, path, err)) || attempt >= g.maxRetries() {
+			return body, err
+		}
+
+		delay := g.backoff(attempt, retryAfter)
+		log.Debugf("Gate throttled %v %v, retrying in %v", method, path, delay)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w, retry cancelled: %v", err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// request sends a single Gate request, the Retry-After header is returned for throttled responses
+func (g *GateClient) request(ctx context.Context, method, path string, dataJSON []byte) ([]byte, time.Duration, error) {
+	var reqBody io.Reader
+	if dataJSON != nil {
+		reqBody = bytes.NewReader(dataJSON)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(g.Endpoint, "/")+path, reqBody)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if dataJSON != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if g.Auth == BasicAuth {
+		req.SetBasicAuth(g.Username, g.Password)
+	}
+
+	resp, err := g.httpClient().Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var retryAfter time.Duration
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, retryAfter, &GateError{Method: method, URL: req.URL.String(), StatusCode: resp.StatusCode, Body: body}
+	}
+	return body, 0, nil
+}
+
+// readAfterWrite returns true for a 404 reading a path the client wrote, Gate can answer before the Spinnaker caches see the write
+func (g *GateClient) readAfterWrite(method, path string, err error) bool {
+	if method != http.MethodGet || StatusCode(err) != http.StatusNotFound {
+		return false
+	}
+	g.writtenMutex.Lock()
+	defer g.writtenMutex.Unlock()
+	return g.written[path]
+}
+
+// wrote records a path the client wrote, read with GET
+func (g *GateClient) wrote(path string) {
+	g.writtenMutex.Lock()
+	defer g.writtenMutex.Unlock()
+	if g.written == nil {
+		g.written = make(map[string]bool)
+	}
+	g.written[path] = true
+}
+
+// deleted forgets the deleted paths, a path ending with '/' forgets every path under it
+func (g *GateClient) deleted(paths ...string) {
+	g.writtenMutex.Lock()
+	defer g.writtenMutex.Unlock()
+	for written := range g.written {
+		for _, path := range paths {
+			if written == path || strings.HasSuffix(path, "/") && strings.HasPrefix(written, path) {
+				delete(g.written, written)
+			}
+		}
+	}
+}
+
+func applicationPath(appName string) string {
+	return "/applications/" + url.PathEscape(appName) + "?expand=false"
+}
+
+func pipelinePath(appName, pipeName string) string {
+	return "/applications/" + url.PathEscape(appName) + "/pipelineConfigs/" + url.PathEscape(pipeName)
+}
+
+func (g *GateClient) maxRetries() int {
+	if g.MaxRetries == 0 {
+		return DefaultMaxRetries
+	}
+	return g.MaxRetries
+}
+
+// backoff returns the delay before a retry, the delay doubles on each attempt with a random jitter
+// the Retry-After delay requested by Gate is used when longer
+func (g *GateClient) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	delay := g.RetryDelay
+	if delay == 0 {
+		delay = DefaultRetryDelay
+	}
+	for i := 0; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	// full jitter on the upper half, concurrent retries don't hit Gate at the same time
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+
+	if retryAfter > maxRetryDelay {
+		retryAfter = maxRetryDelay
+	}
+	if retryAfter > delay {
+		return retryAfter
+	}
+	return delay
+}
+
+// login establishes the Gate session for ldap auth, the session cookie is kept in the http client jar
+func (g *GateClient) login(ctx context.Context) error {
+	if g.Auth != LdapAuth {
+		return nil
+	}
+	g.loginMutex.Lock()
+	defer g.loginMutex.Unlock()
+	if g.loggedIn {
+		return nil
+	}
+
+	form := url.Values{}
+	form.Add("username", g.Username)
+	form.Add("password", g.Password)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(g.Endpoint, "/")+"/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := g.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("ldap authentication failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return &GateError{Method: req.Method, URL: req.URL.String(), StatusCode: resp.StatusCode, Body: body}
+	}
+
+	g.loggedIn = true
+	return nil
+}
+
+func (g *GateClient) httpClient() *http.Client {
+	g.clientOnce.Do(func() {
+		if g.HTTPClient == nil {
+			jar, _ := cookiejar.New(nil)
+			g.HTTPClient = &http.Client{Jar: jar}
+		}
+	})
+	return g.HTTPClient
+}
@@ -0,0 +1,280 @@
+package spincli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-test/deep"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGateBasicAuth(t *testing.T) {
+	gate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "test", "attributes": {"name": "test", "email": "test@example.com"}}`))
+	}))
+	defer gate.Close()
+
+	g := GateClient{Endpoint: gate.URL, Auth: BasicAuth, Username: "user", Password: "pass"}
+	app, err := g.GetApplication(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(string(app), `{"name": "test", "email": "test@example.com"}`); diff != nil {
+		t.Error(diff)
+	}
+
+	g = GateClient{Endpoint: gate.URL, Auth: BasicAuth, Username: "user", Password: "wrong"}
+	_, err = g.GetApplication(context.Background(), "test")
+	if StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized error, got: %v", err)
+	}
+}
+
+func TestGateNotFound(t *testing.T) {
+	gate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Pipeline not found"}`))
+	}))
+	defer gate.Close()
+
+	g := GateClient{Endpoint: gate.URL}
+	_, err := g.GetPipeline(context.Background(), "test", "missing")
+	gateErr, ok := err.(*GateError)
+	if !ok {
+		t.Fatalf("expected a Gate error, got: %v", err)
+	}
+	if diff := deep.Equal(*gateErr, GateError{
+		Method:     http.MethodGet,
+		URL:        gate.URL + "/applications/test/pipelineConfigs/missing",
+		StatusCode: http.StatusNotFound,
+		Body:       []byte(`{"message": "Pipeline not found"}`),
+	}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestGateSavePipeline(t *testing.T) {
+	saved := make(map[string]interface{})
+	gate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/applications/test/pipelineConfigs/existing":
+			_, _ = w.Write([]byte(`{"id": "existing-id", "name": "existing"}`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && r.URL.Path == "/pipelines":
+			pipeline := make(map[string]interface{})
+			if err := json.NewDecoder(r.Body).Decode(&pipeline); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			saved[pipeline["name"].(string)] = pipeline["id"]
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer gate.Close()
+
+	g := GateClient{Endpoint: gate.URL}
+	for _, name := range []string{"existing", "new"} {
+		err := g.SavePipeline(context.Background(), "test", name, map[string]interface{}{"application": "test", "name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if diff := deep.Equal(saved, map[string]interface{}{"existing": "existing-id", "new": nil}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestGateTask(t *testing.T) {
+	polls := 0
+	gate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/tasks":
+			_, _ = w.Write([]byte(`{"ref": "/tasks/1"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/tasks/1":
+			polls++
+			if polls < 2 {
+				_, _ = w.Write([]byte(`{"status": "RUNNING"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status": "TERMINAL"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer gate.Close()
+
+	g := GateClient{Endpoint: gate.URL}
+	err := g.SaveApplication(context.Background(), "test", map[string]interface{}{"name": "test"})
+	if err == nil {
+		t.Fatal("expected the terminal task to fail")
+	}
+	if polls != 2 {
+		t.Errorf("expected 2 task polls, got %d", polls)
+	}
+}
+
+func TestGateRetryThrottled(t *testing.T) {
+	requests := 0
+	gate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "deploy"}`))
+	}))
+	defer gate.Close()
+
+	g := GateClient{Endpoint: gate.URL, RetryDelay: time.Millisecond}
+	pipe, err := g.GetPipeline(context.Background(), "test", "deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(string(pipe), `{"name": "deploy"}`); diff != nil {
+		t.Error(diff)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+	requests = 0
+	g = GateClient{Endpoint: gate.URL, MaxRetries: -1}
+	_, err = g.GetPipeline(context.Background(), "test", "deploy")
+	if StatusCode(err) != http.StatusTooManyRequests || requests != 1 {
+		t.Errorf("expected a throttled error without retries, got %v after %d requests", err, requests)
+	}
+}
+
+func TestGateBackoff(t *testing.T) {
+	g := GateClient{RetryDelay: time.Second}
+	for attempt, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		delay := g.backoff(attempt, 0)
+		if delay < max/2 || delay > max {
+			t.Errorf("attempt %d: expected a delay between %v and %v, got %v", attempt, max/2, max, delay)
+		}
+	}
+	if delay := g.backoff(10, 0); delay > maxRetryDelay {
+		t.Errorf("expected the delay capped at %v, got %v", maxRetryDelay, delay)
+	}
+	if delay := g.backoff(0, 5*time.Second); delay != 5*time.Second {
+		t.Errorf("expected the Retry-After delay, got %v", delay)
+	}
+}
+
+func TestGateCancelledContext(t *testing.T) {
+	calls := 0
+	gate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"name": "test", "attributes": {"name": "test"}}`))
+	}))
+	defer gate.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a := ApplicationAPI{Gate: Gate{Client: &GateClient{Endpoint: gate.URL}}}
+	if _, err := a.Get(ctx, "test"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cancelled context error, got: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected no call to Gate, got %d", calls)
+	}
+}
+
+func TestPipelineAPIConcurrentCalls(t *testing.T) {
+	gate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer gate.Close()
+
+	// the API is shared, every error names the pipeline of its own call
+	p := &PipelineAPI{Gate: Gate{Client: &GateClient{Endpoint: gate.URL}}}
+	errs := make([]error, 10)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = p.Get(context.Background(), "test", fmt.Sprintf("pipeline-%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if name := fmt.Sprintf("'pipeline-%d'", i); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("expected the error of pipeline %v, got: %v", name, err)
+		}
+	}
+}
+
+func TestGateRetryThrottledForbidden(t *testing.T) {
+	requests := 0
+	body := `{"message": "Request repeated too quickly"}`
+	gate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(body))
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "deploy"}`))
+	}))
+	defer gate.Close()
+
+	g := GateClient{Endpoint: gate.URL, RetryDelay: time.Millisecond}
+	if _, err := g.GetPipeline(context.Background(), "test", "deploy"); err != nil || requests != 3 {
+		t.Errorf("expected the throttled 403 retried, got %v after %d requests", err, requests)
+	}
+
+	// a 403 without the throttling message is a denied access
+	requests = 0
+	body = `{"message": "Access denied to application test"}`
+	_, err := g.GetPipeline(context.Background(), "test", "deploy")
+	if StatusCode(err) != http.StatusForbidden || requests != 1 {
+		t.Errorf("expected a denied access without retries, got %v after %d requests", err, requests)
+	}
+}
+
+func TestGateRetryReadAfterWrite(t *testing.T) {
+	reads := 0
+	gate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+		case r.URL.Path == "/applications/test/pipelineConfigs/deploy":
+			reads++
+			// the saved pipeline is found on the third read
+			if reads < 3 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id": "deploy-id", "name": "deploy"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer gate.Close()
+
+	g := GateClient{Endpoint: gate.URL, RetryDelay: time.Millisecond}
+	if err := g.SavePipeline(context.Background(), "test", "deploy", map[string]interface{}{"name": "deploy"}); err != nil {
+		t.Fatal(err)
+	}
+	if reads != 1 {
+		t.Errorf("expected the new pipeline read once, got %d reads", reads)
+	}
+	if _, err := g.GetPipeline(context.Background(), "test", "deploy"); err != nil || reads != 3 {
+		t.Errorf("expected the saved pipeline read again until found, got %v after %d reads", err, reads)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// Package gatetest provides a fake Gate server storing applications and pipelines in memory
+package gatetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"swinch/spincli"
+	"sync"
+)
+
+// Server is a fake Gate, orca tasks complete as soon as they are submitted
+type Server struct {
+	*httptest.Server
+
+	mutex        sync.Mutex
+	applications map[string]map[string]interface{}
+	pipelines    map[string]map[string]map[string]interface{}
+	tasks        []string
+	ids          int
+	throttled    int
+}
+
+// NewServer starts a fake Gate server, the caller should Close it when finished
+func NewServer() *Server {
+	s := &Server{
+		applications: make(map[string]map[string]interface{}),
+		pipelines:    make(map[string]map[string]map[string]interface{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Client returns a Gate client for the fake server
+func (s *Server) Client() *spincli.GateClient {
+	return &spincli.GateClient{Endpoint: s.URL, Timeout: spincli.DefaultTimeout}
+}
+
+// Application returns a stored application, nil if it does not exist
+func (s *Server) Application(appName string) map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.applications[appName]
+}
+
+// Pipeline returns a stored pipeline, nil if it does not exist
+func (s *Server) Pipeline(appName, pipeName string) map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.pipelines[appName][pipeName]
+}
+
+// Throttle answers the next requests with the 403 Gate returns for a burst of requests
+func (s *Server) Throttle(requests int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.throttled = requests
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.throttled > 0 {
+		s.throttled--
+		writeError(w, http.StatusForbidden, "Request repeated too quickly")
+		return
+	}
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && len(path) == 2 && path[0] == "applications":
+		s.getApplication(w, path[1])
+	case r.Method == http.MethodGet && len(path) == 3 && path[0] == "applications" && path[2] == "pipelineConfigs":
+		s.listPipelines(w, path[1])
+	case r.Method == http.MethodGet && len(path) == 4 && path[0] == "applications" && path[2] == "pipelineConfigs":
+		s.getPipeline(w, path[1], path[3])
+	case r.Method == http.MethodPost && len(path) == 1 && path[0] == "pipelines":
+		s.savePipeline(w, r)
+	case r.Method == http.MethodDelete && len(path) == 3 && path[0] == "pipelines":
+		delete(s.pipelines[path[1]], path[2])
+	case r.Method == http.MethodPost && len(path) == 1 && path[0] == "tasks":
+		s.runTask(w, r)
+	case r.Method == http.MethodGet && len(path) == 2 && path[0] == "tasks":
+		s.getTask(w, path[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) getApplication(w http.ResponseWriter, appName string) {
+	app, ok := s.applications[appName]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Application not found (id: %v)", appName))
+		return
+	}
+	writeJSON(w, map[string]interface{}{"name": appName, "attributes": app})
+}
+
+func (s *Server) listPipelines(w http.ResponseWriter, appName string) {
+	names := make([]string, 0)
+	for name := range s.pipelines[appName] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pipes := make([]interface{}, 0)
+	for _, name := range names {
+		pipes = append(pipes, s.pipelines[appName][name])
+	}
+	writeJSON(w, pipes)
+}
+
+func (s *Server) getPipeline(w http.ResponseWriter, appName, pipeName string) {
+	pipe, ok := s.pipelines[appName][pipeName]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Pipeline config (id: %v) not found", pipeName))
+		return
+	}
+	writeJSON(w, pipe)
+}
+
+func (s *Server) savePipeline(w http.ResponseWriter, r *http.Request) {
+	pipe := make(map[string]interface{})
+	if err := json.NewDecoder(r.Body).Decode(&pipe); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	appName, _ := pipe["application"].(string)
+	pipeName, _ := pipe["name"].(string)
+	if _, ok := s.applications[appName]; !ok {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("Access denied to application %v", appName))
+		return
+	}
+
+	if _, ok := pipe["id"]; !ok {
+		s.ids++
+		pipe["id"] = fmt.Sprintf("pipeline-%d", s.ids)
+	}
+	if s.pipelines[appName] == nil {
+		s.pipelines[appName] = make(map[string]map[string]interface{})
+	}
+	s.pipelines[appName][pipeName] = pipe
+}
+
+func (s *Server) runTask(w http.ResponseWriter, r *http.Request) {
+	task := new(struct {
+		Job []struct {
+			Type        string                 `json:"type"`
+			Application map[string]interface{} `json:"application"`
+		} `json:"job"`
+	})
+	if err := json.NewDecoder(r.Body).Decode(task); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := "SUCCEEDED"
+	for _, job := range task.Job {
+		appName, _ := job.Application["name"].(string)
+		switch job.Type {
+		case "createApplication":
+			s.applications[appName] = job.Application
+		case "deleteApplication":
+			delete(s.applications, appName)
+			delete(s.pipelines, appName)
+		default:
+			status = "TERMINAL"
+		}
+	}
+
+	s.tasks = append(s.tasks, status)
+	writeJSON(w, map[string]interface{}{"ref": fmt.Sprintf("/tasks/%d", len(s.tasks))})
+}
+
+func (s *Server) getTask(w http.ResponseWriter, id string) {
+	var index int
+	if _, err := fmt.Sscan(id, &index); err != nil || index < 1 || index > len(s.tasks) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Task not found (id: %v)", id))
+		return
+	}
+	writeJSON(w, map[string]interface{}{"id": id, "status": s.tasks[index-1]})
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "message": message})
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package spincli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Memory is an in-memory Spinnaker, applications and pipelines are kept as the JSON Spinnaker would return
+type Memory struct {
+	mutex        sync.Mutex
+	applications map[string][]byte
+	pipelines    map[string]map[string][]byte
+}
+
+type memoryApplications struct {
+	*Memory
+}
+
+type memoryPipelines struct {
+	*Memory
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		applications: make(map[string][]byte),
+		pipelines:    make(map[string]map[string][]byte),
+	}
+}
+
+// Applications returns the application backend of the in-memory Spinnaker
+func (m *Memory) Applications() ApplicationBackend {
+	return memoryApplications{m}
+}
+
+// Pipelines returns the pipeline backend of the in-memory Spinnaker
+func (m *Memory) Pipelines() PipelineBackend {
+	return memoryPipelines{m}
+}
+
+func (m memoryApplications) Get(_ context.Context, appName string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.applications[appName], nil
+}
+
+func (m memoryApplications) Save(_ context.Context, appName string, spec interface{}) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	app, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	m.applications[appName] = app
+	return nil
+}
+
+func (m memoryApplications) Delete(_ context.Context, appName string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.applications, appName)
+	delete(m.pipelines, appName)
+	return nil
+}
+
+func (m memoryPipelines) Get(_ context.Context, appName, pipeName string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.pipelines[appName][pipeName], nil
+}
+
+func (m memoryPipelines) List(_ context.Context, appName string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	names := make([]string, 0)
+	for name := range m.pipelines[appName] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pipes := make([]json.RawMessage, 0)
+	for _, name := range names {
+		pipes = append(pipes, m.pipelines[appName][name])
+	}
+	return json.Marshal(pipes)
+}
+
+func (m memoryPipelines) Save(_ context.Context, appName, pipeName string, spec interface{}) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.applications[appName]; !ok {
+		return fmt.Errorf("attempting action on pipeline '%v' from application '%v' which does not exist", pipeName, appName)
+	}
+	pipe, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	if m.pipelines[appName] == nil {
+		m.pipelines[appName] = make(map[string][]byte)
+	}
+	m.pipelines[appName][pipeName] = pipe
+	return nil
+}
+
+func (m memoryPipelines) Delete(_ context.Context, appName, pipeName string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.pipelines[appName], pipeName)
+	return nil
+}
@@ -13,85 +13,94 @@ governing permissions and limitations under the License.
 package spincli
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	log "github.com/sirupsen/logrus"
+	"net/http"
 )
 
+// PipelineAPI keeps no request state, it is shared by concurrent calls
 type PipelineAPI struct {
-	appName  string
-	pipeName string
-	SpinCLI
+	Gate
 }
 
-func (p *PipelineAPI) NotFound() error {
-	return errors.New("404 ")
-}
-
-func (p *PipelineAPI) NotAllowed() error {
-	return errors.New("403 ")
-}
-
-func (p *PipelineAPI) unhandledNotFound() error {
-	return errors.New("unhandled response 404: ")
-}
-
-func (p *PipelineAPI) unhandledNotAllowed() error {
-	return errors.New("unhandled response 403: ")
-}
-
-func (p *PipelineAPI) renameNotAllowed() error {
-	return errors.New("400 ")
+func (p PipelineAPI) Get(ctx context.Context, appName, pipeName string) ([]byte, error) {
+	gate, err := p.gate()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := gate.timeoutContext(ctx)
+	defer cancel()
+	pipe, err := gate.GetPipeline(ctx, appName, pipeName)
+	log.Debugf("Spinnaker get response: %v", err)
+	if StatusCode(err) == http.StatusNotFound {
+		log.Infof("Pipeline '%v' not found", pipeName)
+		return nil, nil
+	}
+	return pipe, p.status(appName, pipeName, err)
 }
 
-func (p *PipelineAPI) Get(appName, pipeName string) []byte {
-	p.appName = appName
-	p.pipeName = pipeName
-	args := []string{"pipeline", "get", "--application", p.appName, "--name", p.pipeName}
-	buffer, err := p.executePipeCmd(append(baseArgs, args...))
-	log.Debugf("Spinnaker get response: %v", err)
-	p.status(err)
-	return buffer.Bytes()
+func (p PipelineAPI) List(ctx context.Context, appName string) ([]byte, error) {
+	gate, err := p.gate()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := gate.timeoutContext(ctx)
+	defer cancel()
+	pipes, err := gate.ListPipelines(ctx, appName)
+	log.Debugf("Spinnaker list response: %v", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pipelines of application '%v': %w", appName, err)
+	}
+	return pipes, nil
 }
 
-func (p PipelineAPI) Save(appName, pipeName, filePath string) {
-	p.appName = appName
-	p.pipeName = pipeName
-	args := []string{"pipeline", "save", "--file", filePath}
-	_, err := p.executePipeCmd(append(baseArgs, args...))
-	p.status(err)
-	if err == nil {
-		log.Infof("Pipeline '%v' in application '%v' updated successfuly", p.pipeName, p.appName)
+func (p PipelineAPI) Save(ctx context.Context, appName, pipeName string, spec interface{}) error {
+	gate, err := p.gate()
+	if err != nil {
+		return err
 	}
-	defer p.rmTmp(filePath)
+	ctx, cancel := gate.timeoutContext(ctx)
+	defer cancel()
+	err = gate.SavePipeline(ctx, appName, pipeName, spec)
+	if err != nil {
+		return p.status(appName, pipeName, err)
+	}
+	log.Infof("Pipeline '%v' in application '%v' updated successfuly", pipeName, appName)
+	return nil
 }
 
-func (p PipelineAPI) Delete(appName, pipeName string) {
-	p.appName = appName
-	p.pipeName = pipeName
-	args := []string{"pipeline", "delete", "--application", p.appName, "--name", p.pipeName}
-	_, err := p.executePipeCmd(append(baseArgs, args...))
+func (p PipelineAPI) Delete(ctx context.Context, appName, pipeName string) error {
+	gate, err := p.gate()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := gate.timeoutContext(ctx)
+	defer cancel()
+	err = gate.DeletePipeline(ctx, appName, pipeName)
+	if StatusCode(err) == http.StatusNotFound {
+		log.Infof("Pipeline '%v' not found", pipeName)
+		return nil
+	}
 	if err != nil {
-		p.status(err)
-	} else {
-		log.Infof("Delete pipeline '%v' success", p.pipeName)
+		return p.status(appName, pipeName, err)
 	}
+	log.Infof("Delete pipeline '%v' success", pipeName)
+	return nil
 }
 
-func (p *PipelineAPI) status(err error) {
+func (p PipelineAPI) status(appName, pipeName string, err error) error {
 	if err != nil {
-		switch err.Error() {
-		case p.NotFound().Error():
-			log.Infof("Pipeline '%v' not found", p.pipeName)
-		case p.NotAllowed().Error():
-			log.Errorf("Attempting action on pipeline '%v' from application '%v' which does not exist", p.pipeName, p.appName)
-		case p.unhandledNotFound().Error():
-			log.Fatalf("Request repeated too quickly")
-		case p.unhandledNotAllowed().Error():
-			log.Fatalf("Request repeated too quickly")
-		case p.renameNotAllowed().Error():
-			log.Fatalf("Renaming an existing pipeline is not supported")
+		switch {
+		case Throttled(err):
+			return fmt.Errorf("request throttled by Gate, retries exhausted: %w", err)
+		case StatusCode(err) == http.StatusForbidden:
+			return fmt.Errorf("attempting action on pipeline '%v' from application '%v' which does not exist: %w", pipeName, appName, err)
+		case StatusCode(err) == http.StatusBadRequest:
+			return fmt.Errorf("renaming an existing pipeline is not supported: %w", err)
 		default:
-			log.Fatalf("Failed to check pipeline status: %v", err)
+			return fmt.Errorf("failed to check pipeline '%v' status: %w", pipeName, err)
 		}
 	}
+	return nil
 }
@@ -13,43 +13,45 @@ governing permissions and limitations under the License.
 package spincli
 
 import (
-	"bytes"
-	log "github.com/sirupsen/logrus"
-	"github.com/spf13/cobra"
-	spincmd "github.com/spinnaker/spin/cmd"
-	spinapplication "github.com/spinnaker/spin/cmd/application"
-	spinpipeline "github.com/spinnaker/spin/cmd/pipeline"
-	"os"
+	"fmt"
+	"swinch/cmd/config"
+	"sync"
 )
 
-type SpinCLI struct {
-}
+var (
+	currentContextOnce   sync.Once
+	currentContextClient *GateClient
+	currentContextErr    error
+)
 
-func (s SpinCLI) getCmd() (*bytes.Buffer, *cobra.Command, *spincmd.RootOptions) {
-	buffer := new(bytes.Buffer)
-	cmd, options := spincmd.NewCmdRoot(buffer, buffer)
-	return buffer, cmd, options
+// Gate resolves the Gate client used by the Spinnaker APIs, the current context client is used if none is set
+type Gate struct {
+	Client *GateClient
 }
 
-func (s SpinCLI) executeAppCmd(args []string) (bytes.Buffer, error) {
-	buffer, cmd, options := s.getCmd()
-	appCmd := spinapplication.NewApplicationCmd(options)
-	cmd.AddCommand(appCmd)
-	cmd.SetArgs(args)
-	return *buffer, cmd.Execute()
-}
+// NewGateClient creates a Gate client from the current context of the swinch config
+func NewGateClient() (*GateClient, error) {
+	cd := config.ContextDefinition{}
+	context, err := cd.GetCurrentContextDefinition()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the Gate client config: %w", err)
+	}
 
-func (s SpinCLI) executePipeCmd(args []string) (bytes.Buffer, error) {
-	buffer, cmd, options := s.getCmd()
-	pipeCmd, _ := spinpipeline.NewPipelineCmd(options)
-	cmd.AddCommand(pipeCmd)
-	cmd.SetArgs(args)
-	return *buffer, cmd.Execute()
+	return &GateClient{
+		Endpoint: context.Endpoint,
+		Auth:     context.Auth,
+		Username: context.Username,
+		Password: context.Password,
+		Timeout:  DefaultTimeout,
+	}, nil
 }
 
-func (s SpinCLI) rmTmp(file string) {
-	errRemove := os.Remove(file)
-	if errRemove != nil {
-		log.Warnf("error removing the temp file: %v", errRemove)
+func (s Gate) gate() (*GateClient, error) {
+	if s.Client != nil {
+		return s.Client, nil
 	}
+	currentContextOnce.Do(func() {
+		currentContextClient, currentContextErr = NewGateClient()
+	})
+	return currentContextClient, currentContextErr
 }